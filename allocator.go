@@ -0,0 +1,248 @@
+package bytespool
+
+import (
+	"errors"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	ErrDoubleRelease  = errors.New("bytespool: byte slice released twice")
+	ErrUnknownRelease = errors.New("bytespool: byte slice not acquired from this allocator")
+)
+
+// Allocator hands out and takes back byte slices.
+// It is implemented by CapacityPools, TaggedPool, HeapAllocator and DebugAllocator,
+// so that libraries taking an Allocator can be given a plain heap or an instrumented allocator in tests.
+type Allocator interface {
+	// New returns a byte slice of the specified size, which may contain old data.
+	New(size int) []byte
+	// Make returns a byte slice of length 0 and at least the specified capacity.
+	Make(capacity int) []byte
+	// NewBytes returns a byte slice of the specified content.
+	NewBytes(bs []byte) []byte
+	// NewString returns a byte slice of the specified content.
+	NewString(s string) []byte
+	// Append is like the built-in append, the old array is released when a new one is allocated.
+	Append(buf []byte, elems ...byte) []byte
+	// AppendString is like the built-in append, the old array is released when a new one is allocated.
+	AppendString(buf []byte, elems string) []byte
+	// Release takes back a byte slice, which must not be used afterwards.
+	// It reports whether the byte slice is retained for reuse.
+	Release(buf []byte) bool
+}
+
+var (
+	_ Allocator = (*CapacityPools)(nil)
+	_ Allocator = (*TaggedPool)(nil)
+	_ Allocator = HeapAllocator{}
+	_ Allocator = (*DebugAllocator)(nil)
+)
+
+// HeapAllocator is a pass-through Allocator: every byte slice is newly allocated (zeroed) on the heap
+// and Release leaves it to the garbage collector.
+type HeapAllocator struct{}
+
+func (HeapAllocator) New(size int) []byte {
+	return make([]byte, size)
+}
+
+func (HeapAllocator) Make(capacity int) []byte {
+	return make([]byte, 0, capacity)
+}
+
+func (HeapAllocator) NewBytes(bs []byte) []byte {
+	return append(make([]byte, 0, len(bs)), bs...)
+}
+
+func (HeapAllocator) NewString(s string) []byte {
+	return append(make([]byte, 0, len(s)), s...)
+}
+
+func (HeapAllocator) Append(buf []byte, elems ...byte) []byte {
+	return append(buf, elems...)
+}
+
+func (HeapAllocator) AppendString(buf []byte, elems string) []byte {
+	return append(buf, elems...)
+}
+
+// Release always reports false, nothing is retained.
+func (HeapAllocator) Release(buf []byte) bool {
+	return false
+}
+
+const (
+	// poison is written over the released byte slices by DebugAllocator.
+	poison = 0xdb
+
+	// maxReleased bounds the released arrays remembered by DebugAllocator.
+	maxReleased = 1 << 16
+)
+
+// Allocation is a byte slice handed out by a DebugAllocator and not yet released.
+type Allocation struct {
+	Capacity int
+	Caller   string // file:line of the caller of New, Make, NewBytes, NewString or Append
+	Time     time.Time
+}
+
+// DebugAllocator wraps an Allocator to find misuses, at the cost of a lock and a map per operation:
+//   - releasing a byte slice twice, or one it did not hand out, is reported to OnError
+//     and the byte slice is not released to the wrapped allocator;
+//   - released byte slices are overwritten with 0xdb, so that uses after release show up;
+//   - Outstanding lists the byte slices not yet released, with their callers.
+//
+// A byte slice released twice is only detected as such while the wrapped allocator has not handed it out again,
+// otherwise it is reported as not handed out.
+type DebugAllocator struct {
+	// OnError receives the misuses, the default panics.
+	OnError func(err error)
+
+	a           Allocator
+	mu          sync.Mutex
+	outstanding map[*byte]Allocation // by array pointer
+	released    map[uintptr]bool     // array addresses released and not handed out again
+}
+
+// NewDebugAllocator returns a DebugAllocator wrapping a, the default pools if nil.
+func NewDebugAllocator(a Allocator) *DebugAllocator {
+	if a == nil {
+		a = Default()
+	}
+	return &DebugAllocator{
+		a:           a,
+		outstanding: make(map[*byte]Allocation),
+		released:    make(map[uintptr]bool),
+	}
+}
+
+// arrayPtr returns the pointer to the underlying array of buf, nil if its capacity is 0.
+func arrayPtr(buf []byte) *byte {
+	if cap(buf) == 0 {
+		return nil
+	}
+	return &buf[:1][0]
+}
+
+func (d *DebugAllocator) track(buf []byte) []byte {
+	ptr := arrayPtr(buf)
+	if ptr == nil {
+		return buf
+	}
+	caller := "unknown"
+	if _, file, line, ok := runtime.Caller(2); ok {
+		caller = file + ":" + strconv.Itoa(line)
+	}
+	d.mu.Lock()
+	delete(d.released, reflect.ValueOf(ptr).Pointer())
+	d.outstanding[ptr] = Allocation{Capacity: cap(buf), Caller: caller, Time: time.Now()}
+	d.mu.Unlock()
+	return buf
+}
+
+func (d *DebugAllocator) New(size int) []byte {
+	return d.track(d.a.New(size))
+}
+
+func (d *DebugAllocator) Make(capacity int) []byte {
+	return d.track(d.a.Make(capacity))
+}
+
+func (d *DebugAllocator) NewBytes(bs []byte) []byte {
+	return d.track(d.a.NewBytes(bs))
+}
+
+func (d *DebugAllocator) NewString(s string) []byte {
+	return d.track(d.a.NewString(s))
+}
+
+func (d *DebugAllocator) Append(buf []byte, elems ...byte) []byte {
+	if cap(buf) >= len(buf)+len(elems) {
+		return append(buf, elems...)
+	}
+	bbuf := d.track(d.a.Make(growCap(cap(buf), len(buf)+len(elems))))
+	bbuf = append(bbuf, buf...)
+	bbuf = append(bbuf, elems...)
+	d.Release(buf)
+	return bbuf
+}
+
+func (d *DebugAllocator) AppendString(buf []byte, elems string) []byte {
+	if cap(buf) >= len(buf)+len(elems) {
+		return append(buf, elems...)
+	}
+	bbuf := d.track(d.a.Make(growCap(cap(buf), len(buf)+len(elems))))
+	bbuf = append(bbuf, buf...)
+	bbuf = append(bbuf, elems...)
+	d.Release(buf)
+	return bbuf
+}
+
+// growCap returns the capacity to grow a byte slice of capacity c to length m,
+// doubled like the built-in append so that repeated appends are amortized.
+func growCap(c, m int) int {
+	if m < 2*c {
+		return 2 * c
+	}
+	return m
+}
+
+// Release poisons buf and releases it to the wrapped allocator,
+// unless it is released twice or was not handed out by d.
+func (d *DebugAllocator) Release(buf []byte) bool {
+	ptr := arrayPtr(buf)
+	if ptr == nil {
+		return false
+	}
+	addr := reflect.ValueOf(ptr).Pointer()
+	d.mu.Lock()
+	_, ok := d.outstanding[ptr]
+	if ok {
+		delete(d.outstanding, ptr)
+		if len(d.released) >= maxReleased {
+			d.released = make(map[uintptr]bool)
+		}
+		d.released[addr] = true
+	}
+	double := !ok && d.released[addr]
+	d.mu.Unlock()
+
+	if !ok {
+		if double {
+			d.report(ErrDoubleRelease)
+		} else {
+			d.report(ErrUnknownRelease)
+		}
+		return false
+	}
+	buf = buf[:cap(buf)]
+	for i := range buf {
+		buf[i] = poison
+	}
+	return d.a.Release(buf)
+}
+
+func (d *DebugAllocator) report(err error) {
+	if d.OnError != nil {
+		d.OnError(err)
+		return
+	}
+	panic(err)
+}
+
+// Outstanding returns the byte slices handed out and not yet released, oldest first.
+func (d *DebugAllocator) Outstanding() []Allocation {
+	d.mu.Lock()
+	allocs := make([]Allocation, 0, len(d.outstanding))
+	for _, a := range d.outstanding {
+		allocs = append(allocs, a)
+	}
+	d.mu.Unlock()
+	sort.Slice(allocs, func(i, j int) bool { return allocs[i].Time.Before(allocs[j].Time) })
+	return allocs
+}
@@ -0,0 +1,114 @@
+package bytespool
+
+import (
+	"strings"
+	"testing"
+)
+
+// appendAll is a library function taking an Allocator.
+func appendAll(a Allocator, parts ...string) []byte {
+	buf := a.Make(4)
+	for _, s := range parts {
+		buf = a.AppendString(buf, s)
+	}
+	return a.Append(buf, '!')
+}
+
+func TestAllocator(t *testing.T) {
+	for _, a := range []Allocator{
+		NewCapacityPools(2, 64),
+		NewCapacityPools(2, 64).Tagged("x"),
+		HeapAllocator{},
+		NewDebugAllocator(nil),
+		NewDebugAllocator(HeapAllocator{}),
+	} {
+		buf := appendAll(a, "hello", ", ", "world")
+		if string(buf) != "hello, world!" {
+			t.Fatalf("expect hello, world!, but got %q", buf)
+		}
+		a.Release(buf)
+		if buf = a.New(5); len(buf) != 5 {
+			t.Fatalf("expect len is 5, but got %d", len(buf))
+		}
+		a.Release(buf)
+		if buf = a.NewBytes([]byte("ab")); string(buf) != "ab" {
+			t.Fatalf("expect ab, but got %q", buf)
+		}
+		a.Release(buf)
+		if buf = a.NewString("cd"); string(buf) != "cd" {
+			t.Fatalf("expect cd, but got %q", buf)
+		}
+		a.Release(buf)
+	}
+}
+
+func TestHeapAllocator(t *testing.T) {
+	var a HeapAllocator
+	buf := a.New(8)
+	for _, c := range buf {
+		if c != 0 {
+			t.Fatal("expect zeroed memory")
+		}
+	}
+	if cap(a.Make(100)) != 100 || a.Release(buf) {
+		t.Fatal("unexpected heap allocator")
+	}
+}
+
+func TestDebugAllocator(t *testing.T) {
+	var errs []error
+	d := NewDebugAllocator(NewCapacityPools(2, 64))
+	d.OnError = func(err error) { errs = append(errs, err) }
+
+	buf := d.NewString("secret")
+	other := d.Make(8)
+	if n := len(d.Outstanding()); n != 2 {
+		t.Fatalf("expect 2 outstanding byte slices, but got %d", n)
+	}
+	if caller := d.Outstanding()[0].Caller; !strings.Contains(caller, "allocator_test.go") {
+		t.Fatalf("expect the caller is recorded, but got %q", caller)
+	}
+
+	d.Release(buf)
+	if string(buf) == "secret" || buf[0] != poison {
+		t.Fatalf("expect the released byte slice is poisoned, but got %q", buf)
+	}
+	if d.Release(buf) || len(errs) != 1 || errs[0] != ErrDoubleRelease {
+		t.Fatalf("expect ErrDoubleRelease, but got %v", errs)
+	}
+	if d.Release(make([]byte, 8)) || len(errs) != 2 || errs[1] != ErrUnknownRelease {
+		t.Fatalf("expect ErrUnknownRelease, but got %v", errs)
+	}
+	if d.Release(nil) || len(errs) != 2 {
+		t.Fatal("expect releasing nil is ignored")
+	}
+
+	// grows through the debug allocator
+	other = d.AppendString(other, "0123456789")
+	if n := len(d.Outstanding()); n != 1 {
+		t.Fatalf("expect 1 outstanding byte slice, but got %d", n)
+	}
+	d.Release(other)
+	if len(d.Outstanding()) != 0 || len(errs) != 2 {
+		t.Fatal("expect no outstanding byte slices")
+	}
+
+	// grows geometrically like the built-in append
+	heap := NewDebugAllocator(HeapAllocator{})
+	buf = heap.Make(8)
+	for i := 0; i < 100; i++ {
+		buf = heap.Append(buf, 'a')
+	}
+	if cap(buf) != 128 {
+		t.Fatalf("expect the capacity is doubled up to 128, but got %d", cap(buf))
+	}
+	heap.Release(buf)
+
+	d.OnError = nil
+	defer func() {
+		if recover() != ErrUnknownRelease {
+			t.Fatal("expect panic with ErrUnknownRelease")
+		}
+	}()
+	d.Release(make([]byte, 8))
+}
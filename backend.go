@@ -0,0 +1,110 @@
+package bytespool
+
+import (
+	"reflect"
+)
+
+// Backend is the source of the fresh byte slices of a CapacityPools:
+// when a class misses, and for the sizes out of the range of the pools.
+type Backend interface {
+	// Alloc returns a byte slice of length and capacity size, which may contain old data.
+	Alloc(size int) []byte
+	// Free takes back a byte slice from Alloc discarded by the pools,
+	// e.g. released out of range, released into closed pools, or drained.
+	Free(buf []byte)
+	// Managed reports whether the memory is reclaimed by the garbage collector.
+	// Otherwise the pools cache the released byte slices in free lists that survive GC,
+	// and the memory is only returned through Free.
+	Managed() bool
+}
+
+var (
+	_ Backend = MallocBackend{}
+	_ Backend = MakeBackend{}
+	_ Backend = AlignedBackend{}
+	_ Backend = MmapBackend{}
+)
+
+// SetBackend sets the source of the fresh byte slices of this pool, nil for MallocBackend (default).
+// This function is not thread-safe and should be called before any pool operations.
+func (p *CapacityPools) SetBackend(b Backend) {
+	if _, ok := b.(MallocBackend); ok {
+		b = nil
+	}
+	p.backend = b
+	p.manual = b != nil && !b.Managed()
+}
+
+// GetBackend returns the source of the fresh byte slices of this pool.
+func (p *CapacityPools) GetBackend() Backend {
+	if p.backend == nil {
+		return MallocBackend{}
+	}
+	return p.backend
+}
+
+// alloc returns a fresh byte slice from the backend.
+func (p *CapacityPools) alloc(len, cap int) []byte {
+	if NoPool {
+		return make([]byte, len, cap)
+	}
+	if p.backend == nil {
+		return Bytes(len, cap)
+	}
+	return p.backend.Alloc(cap)[:len]
+}
+
+// addrOf returns the address of the array of buf.
+func addrOf(buf []byte) uintptr {
+	return reflect.ValueOf(buf).Pointer()
+}
+
+// MallocBackend allocates dirty memory with Bytes, it is the default.
+type MallocBackend struct{}
+
+func (MallocBackend) Alloc(size int) []byte {
+	return Bytes(size, size)
+}
+
+func (MallocBackend) Free(buf []byte) {}
+
+func (MallocBackend) Managed() bool {
+	return true
+}
+
+// MakeBackend allocates zeroed memory with make.
+type MakeBackend struct{}
+
+func (MakeBackend) Alloc(size int) []byte {
+	return make([]byte, size)
+}
+
+func (MakeBackend) Free(buf []byte) {}
+
+func (MakeBackend) Managed() bool {
+	return true
+}
+
+// AlignedBackend allocates zeroed memory whose address is a multiple of Align,
+// e.g. 64 for cache lines or 4096 for direct I/O. Align must be a power of two, 0 or 1 for no alignment.
+type AlignedBackend struct {
+	Align int
+}
+
+func (b AlignedBackend) Alloc(size int) []byte {
+	if b.Align <= 1 {
+		return make([]byte, size)
+	}
+	buf := make([]byte, size+b.Align-1)
+	off := 0
+	if rem := int(addrOf(buf) & uintptr(b.Align-1)); rem != 0 {
+		off = b.Align - rem
+	}
+	return buf[off : off+size : off+size]
+}
+
+func (AlignedBackend) Free(buf []byte) {}
+
+func (AlignedBackend) Managed() bool {
+	return true
+}
@@ -0,0 +1,18 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package bytespool
+
+// MmapBackend allocates zeroed memory outside the Go heap with anonymous mmap, returned to the OS by Free.
+// On this platform, it falls back to make.
+type MmapBackend struct{}
+
+func (MmapBackend) Alloc(size int) []byte {
+	return make([]byte, size)
+}
+
+func (MmapBackend) Free(buf []byte) {}
+
+func (MmapBackend) Managed() bool {
+	return true
+}
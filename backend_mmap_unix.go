@@ -0,0 +1,36 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package bytespool
+
+import (
+	"syscall"
+)
+
+// MmapBackend allocates zeroed memory outside the Go heap with anonymous mmap, returned to the OS by Free.
+// It keeps large buffers out of the GC heap and its accounting (e.g. GOGC and GOMEMLIMIT).
+// The byte slices must not be used after they are released, and must not store Go pointers.
+// On platforms without mmap, it falls back to make.
+type MmapBackend struct{}
+
+func (MmapBackend) Alloc(size int) []byte {
+	if size == 0 {
+		return []byte{}
+	}
+	buf, err := syscall.Mmap(-1, 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		panic("bytespool: mmap: " + err.Error())
+	}
+	return buf
+}
+
+// Free unmaps buf, byte slices not allocated by Alloc are ignored.
+func (MmapBackend) Free(buf []byte) {
+	if cap(buf) > 0 {
+		_ = syscall.Munmap(buf[:cap(buf)])
+	}
+}
+
+func (MmapBackend) Managed() bool {
+	return false
+}
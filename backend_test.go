@@ -0,0 +1,150 @@
+package bytespool
+
+import (
+	"testing"
+)
+
+type countingBackend struct {
+	allocs, frees int
+}
+
+func (b *countingBackend) Alloc(size int) []byte {
+	b.allocs++
+	return make([]byte, size)
+}
+
+func (b *countingBackend) Free(buf []byte) {
+	b.frees++
+}
+
+func (b *countingBackend) Managed() bool {
+	return false
+}
+
+func TestCapacityPools_SetBackend(t *testing.T) {
+	skipNoPool(t)
+	p := NewCapacityPools(8, 64)
+	if _, ok := p.GetBackend().(MallocBackend); !ok {
+		t.Fatal("expect the default backend is MallocBackend")
+	}
+	p.SetBackend(MallocBackend{})
+	if p.backend != nil {
+		t.Fatal("expect MallocBackend is the fast path")
+	}
+
+	b := &countingBackend{}
+	p.SetBackend(b)
+	buf := p.New(10)
+	if cap(buf) != 16 || b.allocs != 1 {
+		t.Fatalf("expect the backend allocates the class, but got cap=%d, allocs=%d", cap(buf), b.allocs)
+	}
+	buf[0] = 'x'
+	p.Release(buf)
+	// the unmanaged byte slices are cached in the free list, which is not cleared by GC
+	if buf = p.New(16); buf[0] != 'x' || b.allocs != 1 {
+		t.Fatal("expect the byte slice is reused")
+	}
+
+	// out of range, handed back when released
+	out := p.New(100)
+	if b.allocs != 2 || p.Release(out) || b.frees != 1 {
+		t.Fatalf("unexpected allocs=%d, frees=%d", b.allocs, b.frees)
+	}
+
+	// drained and handed back when closed
+	p.Release(buf)
+	p.Release(p.New(32))
+	p.Close()
+	if b.frees != 3 {
+		t.Fatalf("expect the cached byte slices are freed, but got frees=%d", b.frees)
+	}
+	p.Release(p.New(16))
+	if b.frees != 4 {
+		t.Fatalf("expect the byte slices released into closed pools are freed, but got frees=%d", b.frees)
+	}
+}
+
+func TestMakeBackend(t *testing.T) {
+	p := NewCapacityPools(8, 64)
+	p.SetBackend(MakeBackend{})
+	for _, size := range []int{10, 100} {
+		for _, c := range p.New(size)[:size] {
+			if c != 0 {
+				t.Fatal("expect zeroed memory")
+			}
+		}
+	}
+}
+
+func TestAlignedBackend(t *testing.T) {
+	for _, align := range []int{0, 1, 64, 4096} {
+		buf := AlignedBackend{Align: align}.Alloc(100)
+		if len(buf) != 100 || cap(buf) != 100 {
+			t.Fatalf("expect len and cap are 100, but got %d, %d", len(buf), cap(buf))
+		}
+		if align > 1 && addrOf(buf)%uintptr(align) != 0 {
+			t.Fatalf("expect the address is aligned to %d", align)
+		}
+	}
+
+	p := NewCapacityPools(8, 4096)
+	p.SetBackend(AlignedBackend{Align: 512})
+	buf := p.New(1000)
+	p.Release(buf)
+	if buf = p.New(1000); addrOf(buf)%512 != 0 {
+		t.Fatal("expect the address is aligned to 512")
+	}
+}
+
+func TestMmapBackend(t *testing.T) {
+	skipNoPool(t)
+	p := NewCapacityPools(8, 4096)
+	p.SetBackend(MmapBackend{})
+	buf := p.New(3000)
+	if len(buf) != 3000 || cap(buf) != 4096 {
+		t.Fatalf("unexpected len=%d, cap=%d", len(buf), cap(buf))
+	}
+	for i := range buf {
+		buf[i] = byte(i)
+	}
+	p.Release(buf)
+	if buf = p.New(4096); buf[1] != 1 {
+		t.Fatal("expect the byte slice is reused")
+	}
+	p.Release(buf)
+
+	out := p.New(10000)
+	out[9999] = 1
+	p.Release(out)
+	if len(MmapBackend{}.Alloc(0)) != 0 {
+		t.Fatal("expect an empty byte slice")
+	}
+	p.Close()
+}
+
+func TestCapacityPools_AppendUnmanaged(t *testing.T) {
+	skipNoPool(t)
+	b := &countingBackend{}
+	p := NewCapacityPools(8, 64)
+	p.SetBackend(b)
+	buf := p.New(100)
+	buf = p.Append(buf, 'x')
+	if len(buf) != 101 || b.allocs != 2 || b.frees != 1 {
+		t.Fatalf("expect the old array is freed, but got allocs=%d, frees=%d", b.allocs, b.frees)
+	}
+	// the capacity is doubled, not grown to the exact length
+	buf = p.AppendString(buf, "yz")
+	if len(buf) != 103 || cap(buf) != 200 || buf[100] != 'x' || b.allocs != 2 || b.frees != 1 {
+		t.Fatalf("expect the capacity is doubled, but got cap=%d, allocs=%d, frees=%d", cap(buf), b.allocs, b.frees)
+	}
+	for i := 0; i < 1000; i++ {
+		buf = p.Append(buf, 'a')
+	}
+	if cap(buf) != 1600 || b.allocs != 5 || b.frees != 4 {
+		t.Fatalf("expect 3 more allocations, but got cap=%d, allocs=%d, frees=%d", cap(buf), b.allocs, b.frees)
+	}
+	p.Release(buf)
+	if b.frees != 5 {
+		t.Fatalf("expect the large byte slice is freed, but got frees=%d", b.frees)
+	}
+}
@@ -0,0 +1,156 @@
+package bytespool
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// batchHeaders caches the outer slices of the batches, boxed in the pointers of batchBoxes
+	// so that putting them back does not allocate.
+	batchHeaders sync.Pool // *[][]byte
+	batchBoxes   sync.Pool // *[][]byte, empty
+)
+
+// getBatchHeader returns an empty outer slice of at least n capacity.
+func getBatchHeader(n int) [][]byte {
+	if box, _ := batchHeaders.Get().(*[][]byte); box != nil {
+		bufs := *box
+		*box = nil
+		batchBoxes.Put(box)
+		if cap(bufs) >= n {
+			return bufs[:0]
+		}
+	}
+	return make([][]byte, 0, n)
+}
+
+func putBatchHeader(bufs [][]byte) {
+	if NoPool || cap(bufs) == 0 {
+		return
+	}
+	bufs = bufs[:cap(bufs)]
+	for i := range bufs {
+		bufs[i] = nil
+	}
+	box, _ := batchBoxes.Get().(*[][]byte)
+	if box == nil {
+		box = new([][]byte)
+	}
+	*box = bufs[:0]
+	batchHeaders.Put(box)
+}
+
+// NewBatch returns n byte slices of the specified size from the same class, e.g. for net.Buffers or readv.
+// The cached byte slices are taken in bulk and the outer slice comes from a pool too,
+// release them all with ReleaseBatch.
+// Warning: may contain old data.
+func (p *CapacityPools) NewBatch(n, size int) [][]byte {
+	if n < 0 {
+		n = 0
+	}
+	if size < 0 {
+		size = 0
+	}
+	bufs := getBatchHeader(n)
+	bp := p.getMakePool(size)
+	if bp == nil {
+		for i := 0; i < n; i++ {
+			bufs = append(bufs, p.New(size))
+		}
+		return bufs
+	}
+
+	p.touch(bp)
+	bufs = bp.getBatch(bufs, n, size, p.manual)
+	hits := len(bufs)
+	for len(bufs) < n {
+		bufs = append(bufs, p.alloc(size, bp.capacity))
+	}
+	misses := n - hits
+
+	if p.withStats {
+		atomic.AddUint64(&bp.reqSize, uint64(n*size))
+		if hits > 0 {
+			atomic.AddUint64(&bp.reuseHits, uint64(hits))
+			atomic.AddUint64(&p.reusedBytes, uint64(hits*bp.capacity))
+		}
+		if misses > 0 {
+			atomic.AddUint64(&bp.misses, uint64(misses))
+			atomic.AddUint64(&p.newCount, uint64(misses))
+			atomic.AddUint64(&p.newBytes, uint64(misses*bp.capacity))
+		}
+	}
+	if p.hooks != nil {
+		for i := 0; i < hits; i++ {
+			p.hooks.OnReuse(bp.capacity, size)
+		}
+		for i := 0; i < misses; i++ {
+			p.hooks.OnMiss(bp.capacity, size)
+		}
+	}
+	return bufs
+}
+
+// MakeBatch returns n byte slices of length 0 and the specified capacity, see NewBatch.
+func (p *CapacityPools) MakeBatch(n, capacity int) [][]byte {
+	bufs := p.NewBatch(n, capacity)
+	for i := range bufs {
+		bufs[i] = bufs[i][:0]
+	}
+	return bufs
+}
+
+// ReleaseBatch puts every byte slice back into the pools, then the outer slice,
+// which must not be used afterwards. It returns the number of byte slices put back.
+func (p *CapacityPools) ReleaseBatch(bufs [][]byte) int {
+	n := 0
+	for _, buf := range bufs {
+		if buf != nil && p.Release(buf) {
+			n++
+		}
+	}
+	putBatchHeader(bufs)
+	return n
+}
+
+// getBatch appends up to n cached byte slices of size to dst.
+func (bp *bytesPool) getBatch(dst [][]byte, n, size int, manual bool) [][]byte {
+	if NoPool {
+		return dst
+	}
+	if !manual {
+		for i := 0; i < n; i++ {
+			it, _ := bp.pool.Get().(item)
+			if it == nil {
+				break
+			}
+			dst = append(dst, fromItem(it, size, bp.capacity))
+		}
+		return dst
+	}
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	for i := 0; i < n && len(bp.free) > 0; i++ {
+		last := len(bp.free) - 1
+		dst = append(dst, fromItem(bp.free[last], size, bp.capacity))
+		bp.free[last] = nil
+		bp.free = bp.free[:last]
+	}
+	return dst
+}
+
+// NewBatch returns n byte slices of the specified size from the default pools.
+func NewBatch(n, size int) [][]byte {
+	return Default().NewBatch(n, size)
+}
+
+// MakeBatch returns n byte slices of length 0 and the specified capacity from the default pools.
+func MakeBatch(n, capacity int) [][]byte {
+	return Default().MakeBatch(n, capacity)
+}
+
+// ReleaseBatch puts every byte slice and the outer slice back into the default pools.
+func ReleaseBatch(bufs [][]byte) int {
+	return Default().ReleaseBatch(bufs)
+}
@@ -0,0 +1,67 @@
+package bytespool
+
+import (
+	"testing"
+)
+
+func TestCapacityPools_Batch(t *testing.T) {
+	p := NewCapacityPools(8, 64)
+	p.SetWithStats(true)
+	bufs := p.NewBatch(3, 10)
+	if len(bufs) != 3 {
+		t.Fatalf("expect 3 byte slices, but got %d", len(bufs))
+	}
+	for _, buf := range bufs {
+		if len(buf) != 10 || cap(buf) != 16 {
+			t.Fatalf("expect len 10 and cap 16, but got %d, %d", len(buf), cap(buf))
+		}
+	}
+	if n := p.ReleaseBatch(bufs); n != 3 {
+		t.Fatalf("expect 3 byte slices are put back, but got %d", n)
+	}
+
+	bufs = p.MakeBatch(4, 16)
+	for _, buf := range bufs {
+		if len(buf) != 0 || cap(buf) != 16 {
+			t.Fatalf("expect len 0 and cap 16, but got %d, %d", len(buf), cap(buf))
+		}
+	}
+	p.ReleaseBatch(bufs)
+
+	sum := RuntimeStatsSummary(0, p)
+	if sum.NewCount+sum.ReusedCount != 7 || sum.ReleasedCount != 7 || sum.Outstanding != 0 {
+		t.Fatalf("unexpected stats: %+v", sum)
+	}
+
+	// out of range
+	bufs = p.NewBatch(2, 100)
+	if len(bufs) != 2 || cap(bufs[0]) != 100 || p.ReleaseBatch(bufs) != 0 {
+		t.Fatal("expect the large byte slices bypass the pools")
+	}
+	if bufs = p.NewBatch(-1, 8); len(bufs) != 0 {
+		t.Fatal("expect an empty batch")
+	}
+}
+
+func TestCapacityPools_BatchReuse(t *testing.T) {
+	skipNoPool(t)
+	b := &countingBackend{}
+	p := NewCapacityPools(8, 64)
+	p.SetWithStats(true)
+	p.SetBackend(b)
+	p.ReleaseBatch(p.NewBatch(3, 32))
+	bufs := p.NewBatch(4, 32)
+	if sum := RuntimeStatsSummary(0, p); sum.ReusedCount != 3 || sum.NewCount != 4 {
+		t.Fatalf("expect 3 byte slices are reused in bulk, but got: %+v", sum)
+	}
+	p.ReleaseBatch(bufs)
+}
+
+func TestBatch(t *testing.T) {
+	bufs := MakeBatch(2, 8)
+	if len(bufs) != 2 || cap(bufs[1]) != 8 {
+		t.Fatal("unexpected batch")
+	}
+	ReleaseBatch(bufs)
+	ReleaseBatch(NewBatch(1, 8))
+}
@@ -0,0 +1,175 @@
+package bytespool
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	ErrBudgetExhausted = errors.New("bytespool: budget exhausted")
+	ErrBudgetTooLarge  = errors.New("bytespool: size exceeds the budget")
+)
+
+// Budget limits the capacity bytes acquired from a CapacityPools and not yet released,
+// so that an overloaded process applies backpressure instead of running out of memory.
+// Sub-budgets (e.g. per tenant or per subsystem) also consume their ancestors,
+// so that one of them cannot exhaust the whole budget.
+//
+// The byte slices must be released to the Budget they were acquired from, without growing them:
+// the budget is given back by capacity.
+type Budget struct {
+	p      *CapacityPools
+	parent *Budget
+	max    int
+
+	mu   sync.Mutex
+	used int
+	wake chan struct{} // Closed and replaced on every release, waited on while the budget is full
+}
+
+// NewBudget returns a Budget of maxBytes over p, the default pools if nil.
+func NewBudget(p *CapacityPools, maxBytes int) *Budget {
+	if p == nil {
+		p = Default()
+	}
+	return &Budget{p: p, max: maxBytes, wake: make(chan struct{})}
+}
+
+// Sub returns a sub-budget of maxBytes, its acquisitions also consume b.
+func (b *Budget) Sub(maxBytes int) *Budget {
+	return &Budget{p: b.p, parent: b, max: maxBytes, wake: make(chan struct{})}
+}
+
+// Pools returns the pools the byte slices are acquired from.
+func (b *Budget) Pools() *CapacityPools {
+	return b.p
+}
+
+// Max returns the maximum capacity bytes of the budget.
+func (b *Budget) Max() int {
+	return b.max
+}
+
+// Used returns the capacity bytes acquired and not yet released, including those of the sub-budgets.
+func (b *Budget) Used() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used
+}
+
+// Acquire returns a byte slice of size, waiting until the budget and all its ancestors have room for it
+// or ctx is done, then it returns ctx.Err(), even if there is room for it when ctx is already done.
+// It returns ErrBudgetTooLarge if the capacity can never fit.
+func (b *Budget) Acquire(ctx context.Context, size int) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	n := b.capacity(size)
+	for {
+		wake, err := b.reserve(n)
+		if err != nil {
+			return nil, err
+		}
+		if wake == nil {
+			return b.acquired(size, n), nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-wake:
+		}
+	}
+}
+
+// TryAcquire is like Acquire, but returns ErrBudgetExhausted instead of waiting.
+func (b *Budget) TryAcquire(size int) ([]byte, error) {
+	n := b.capacity(size)
+	wake, err := b.reserve(n)
+	if err != nil {
+		return nil, err
+	}
+	if wake != nil {
+		return nil, ErrBudgetExhausted
+	}
+	return b.acquired(size, n), nil
+}
+
+// Release puts buf back into the pools and gives its capacity back to the budget.
+func (b *Budget) Release(buf []byte) bool {
+	b.unreserve(cap(buf))
+	return b.p.Release(buf)
+}
+
+// capacity returns the capacity of the byte slice New(size) is expected to return.
+func (b *Budget) capacity(size int) int {
+	if size < 0 {
+		size = 0
+	}
+	if bp := b.p.getMakePool(size); bp != nil {
+		return bp.capacity
+	}
+	return size
+}
+
+// acquired takes the byte slice from the pools once n bytes are reserved,
+// a larger byte slice reused by the overflow tier is charged anyway.
+func (b *Budget) acquired(size, n int) []byte {
+	buf := b.p.New(size)
+	if extra := cap(buf) - n; extra > 0 {
+		for x := b; x != nil; x = x.parent {
+			x.mu.Lock()
+			x.used += extra
+			x.mu.Unlock()
+		}
+	}
+	return buf
+}
+
+// reserve consumes n bytes of b and its ancestors.
+// If one of them is full, nothing is consumed and its wake channel is returned.
+func (b *Budget) reserve(n int) (<-chan struct{}, error) {
+	for x := b; x != nil; x = x.parent {
+		x.mu.Lock()
+		if n > x.max {
+			x.mu.Unlock()
+			b.rollback(x, n)
+			return nil, ErrBudgetTooLarge
+		}
+		if x.used+n > x.max {
+			wake := x.wake
+			x.mu.Unlock()
+			b.rollback(x, n)
+			return wake, nil
+		}
+		x.used += n
+		x.mu.Unlock()
+	}
+	return nil, nil
+}
+
+// rollback gives n bytes back to the budgets from b up to stop, excluded.
+func (b *Budget) rollback(stop *Budget, n int) {
+	for x := b; x != stop; x = x.parent {
+		x.release(n)
+	}
+}
+
+// unreserve gives n bytes back to b and its ancestors.
+func (b *Budget) unreserve(n int) {
+	for x := b; x != nil; x = x.parent {
+		x.release(n)
+	}
+}
+
+// release gives n bytes back to b and wakes up its waiters.
+func (b *Budget) release(n int) {
+	b.mu.Lock()
+	b.used -= n
+	if b.used < 0 {
+		b.used = 0
+	}
+	close(b.wake)
+	b.wake = make(chan struct{})
+	b.mu.Unlock()
+}
@@ -0,0 +1,100 @@
+package bytespool
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBudget_TryAcquire(t *testing.T) {
+	b := NewBudget(NewCapacityPools(8, 64), 100)
+	a, err := b.TryAcquire(60)
+	if err != nil || len(a) != 60 || b.Used() != 64 {
+		t.Fatalf("expect 64 bytes are used, but got %d, %v", b.Used(), err)
+	}
+	if _, err = b.TryAcquire(40); err != ErrBudgetExhausted {
+		t.Fatalf("expect ErrBudgetExhausted, but got %v", err)
+	}
+	if _, err = b.TryAcquire(200); err != ErrBudgetTooLarge {
+		t.Fatalf("expect ErrBudgetTooLarge, but got %v", err)
+	}
+	c, err := b.TryAcquire(30)
+	if err != nil || b.Used() != 96 {
+		t.Fatalf("expect 96 bytes are used, but got %d, %v", b.Used(), err)
+	}
+	b.Release(a)
+	b.Release(c)
+	if b.Used() != 0 {
+		t.Fatalf("expect no bytes are used, but got %d", b.Used())
+	}
+	if b.Max() != 100 || NewBudget(nil, 1).Pools() != Default() {
+		t.Fatal("unexpected budget settings")
+	}
+}
+
+func TestBudget_Acquire(t *testing.T) {
+	b := NewBudget(NewCapacityPools(8, 64), 64)
+	a, _ := b.Acquire(context.Background(), 64)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := b.Acquire(ctx, 8); err != context.DeadlineExceeded {
+		t.Fatalf("expect the acquisition times out, but got %v", err)
+	}
+
+	// a done ctx fails even when there is room
+	b.Release(a)
+	done, cancelDone := context.WithCancel(context.Background())
+	cancelDone()
+	if _, err := b.Acquire(done, 8); err != context.Canceled || b.Used() != 0 {
+		t.Fatalf("expect context.Canceled without reservation, but got %v, %d", err, b.Used())
+	}
+	a, _ = b.Acquire(context.Background(), 64)
+
+	got := make(chan []byte)
+	go func() {
+		buf, _ := b.Acquire(context.Background(), 32)
+		got <- buf
+	}()
+	time.Sleep(10 * time.Millisecond)
+	b.Release(a)
+	select {
+	case buf := <-got:
+		if len(buf) != 32 || b.Used() != 32 {
+			t.Fatalf("expect 32 bytes are used, but got %d", b.Used())
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expect the waiter is woken up by the release")
+	}
+}
+
+func TestBudget_Sub(t *testing.T) {
+	root := NewBudget(NewCapacityPools(8, 64), 128)
+	t1 := root.Sub(64)
+	t2 := root.Sub(128)
+
+	a, err := t1.TryAcquire(64)
+	if err != nil || root.Used() != 64 || t1.Used() != 64 {
+		t.Fatalf("expect the sub-budget consumes its parent, but got %d, %v", root.Used(), err)
+	}
+	// the noisy tenant is limited by its own budget
+	if _, err = t1.TryAcquire(8); err != ErrBudgetExhausted {
+		t.Fatalf("expect ErrBudgetExhausted, but got %v", err)
+	}
+	c, err := t2.TryAcquire(64)
+	if err != nil || root.Used() != 128 {
+		t.Fatalf("expect the other tenant acquires, but got %d, %v", root.Used(), err)
+	}
+	// limited by the parent, nothing is consumed
+	if _, err = t2.TryAcquire(8); err != ErrBudgetExhausted || t2.Used() != 64 {
+		t.Fatalf("expect ErrBudgetExhausted without consumption, but got %d, %v", t2.Used(), err)
+	}
+	if _, err = t1.TryAcquire(100); err != ErrBudgetTooLarge || root.Used() != 128 {
+		t.Fatalf("expect ErrBudgetTooLarge without consumption, but got %d, %v", root.Used(), err)
+	}
+	t1.Release(a)
+	t2.Release(c)
+	if root.Used() != 0 || t1.Used() != 0 || t2.Used() != 0 {
+		t.Fatal("expect no bytes are used")
+	}
+}
@@ -3,11 +3,12 @@ package buffer
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"math"
 	"sync/atomic"
-	"unsafe"
 
+	"github.com/fufuok/bytespool"
 	"github.com/fufuok/bytespool/readerpool"
 )
 
@@ -40,6 +41,36 @@ type Buffer struct {
 	// c == 1: there are 2 references in total.
 	c int64
 	B []byte
+	// a: the allocator B was acquired from, nil for the current pools.
+	a bytespool.Allocator
+	// t: acquisition stamp for lifetime statistics, 0 if not recorded.
+	t int64
+}
+
+// Allocator returns the allocator the Buffer grows and releases its byte slices through.
+func (bb *Buffer) Allocator() bytespool.Allocator {
+	return bb.allocator()
+}
+
+// Pools returns the byte slice pool the Buffer grows and releases its byte slices through,
+// nil if it uses another Allocator. Use Allocator to get any Allocator.
+func (bb *Buffer) Pools() *bytespool.CapacityPools {
+	switch a := bb.allocator().(type) {
+	case *bytespool.CapacityPools:
+		return a
+	case *bytespool.TaggedPool:
+		return a.Pools()
+	}
+	return nil
+}
+
+// allocator returns the allocator B was acquired from,
+// so that B goes back to it even if the pools were replaced meanwhile.
+func (bb *Buffer) allocator() bytespool.Allocator {
+	if bb.a != nil {
+		return bb.a
+	}
+	return defaultPools.get()
 }
 
 // Clone returns a copy of the Buffer.B.
@@ -54,7 +85,7 @@ func (bb *Buffer) Bytes() []byte {
 
 // Copy return a copy of the Buffer data.
 func (bb *Buffer) Copy() []byte {
-	return defaultPools.bs.NewBytes(bb.B)
+	return defaultPools.get().NewBytes(bb.B)
 }
 
 // CopyTo same as copy(p, bb.B).
@@ -69,11 +100,6 @@ func (bb *Buffer) String() string {
 	return string(bb.B)
 }
 
-// UnsafeString not immutable.
-func (bb *Buffer) UnsafeString() string {
-	return *(*string)(unsafe.Pointer(&bb.B))
-}
-
 func (bb *Buffer) Len() int {
 	return len(bb.B)
 }
@@ -106,9 +132,10 @@ func (bb *Buffer) Guarantee(n int) {
 	if bSize > math.MaxInt32 {
 		panic(ErrTooLarge)
 	}
-	buf := defaultPools.bs.Make(bSize)
+	a := bb.allocator()
+	buf := a.Make(bSize)
 	buf = append(buf, bb.B...)
-	defaultPools.bs.Release(bb.B)
+	a.Release(bb.B)
 	bb.B = buf
 }
 
@@ -122,16 +149,38 @@ func (bb *Buffer) Truncate(n int) {
 }
 
 // Reset is the same as Truncate(0).
+// With SetShrinkOnReset, a large byte slice is also replaced with a smaller one.
 func (bb *Buffer) Reset() {
+	if c := int(atomic.LoadInt64(&defaultPools.shrink)); c > 0 && cap(bb.B) > c {
+		a := bb.allocator()
+		buf := a.Make(c)
+		a.Release(bb.B)
+		bb.B = buf
+		return
+	}
 	bb.B = bb.B[:0]
 }
 
+// shrinker is implemented by the allocators able to move a byte slice into a smaller class,
+// e.g. *bytespool.CapacityPools.
+type shrinker interface {
+	Shrink(buf []byte) []byte
+}
+
+// Compact moves the data into a smaller byte slice if it only uses a small part of the capacity,
+// see bytespool.CapacityPools.Shrink. The large array is released.
+func (bb *Buffer) Compact() {
+	if s, ok := bb.allocator().(shrinker); ok {
+		bb.B = s.Shrink(bb.B)
+	}
+}
+
 // Write implements io.Writer.
 //
 // The function appends all the data in p to Buffer.B.
 // The returned error is always nil.
 func (bb *Buffer) Write(p []byte) (int, error) {
-	bb.B = appendBytes(bb.B, p...)
+	bb.B = bb.allocator().Append(bb.B, p...)
 	return len(p), nil
 }
 
@@ -145,7 +194,7 @@ func (bb *Buffer) Append(p []byte) {
 // The function appends the byte c to Buffer.B.
 // The returned error is always nil.
 func (bb *Buffer) WriteByte(c byte) error {
-	bb.B = appendBytes(bb.B, c)
+	bb.B = bb.allocator().Append(bb.B, c)
 	return nil
 }
 
@@ -159,7 +208,7 @@ func (bb *Buffer) AppendByte(c byte) {
 // The function appends the s to Buffer.B.
 // The returned error is always nil.
 func (bb *Buffer) WriteString(s string) (int, error) {
-	bb.B = appendString(bb.B, s)
+	bb.B = bb.allocator().AppendString(bb.B, s)
 	return len(s), nil
 }
 
@@ -168,14 +217,48 @@ func (bb *Buffer) AppendString(s string) {
 	bb.B = append(bb.B, s...)
 }
 
+// Join appends the parts with sep between them to Buffer.B, growing it once to the exact size.
+func (bb *Buffer) Join(sep []byte, parts ...[]byte) {
+	if len(parts) == 0 {
+		return
+	}
+	n := len(sep) * (len(parts) - 1)
+	for _, b := range parts {
+		n += len(b)
+	}
+	bb.Guarantee(n)
+	bb.B = append(bb.B, parts[0]...)
+	for _, b := range parts[1:] {
+		bb.B = append(bb.B, sep...)
+		bb.B = append(bb.B, b...)
+	}
+}
+
+// ConcatStrings appends the strings to Buffer.B, growing it once to the exact size.
+func (bb *Buffer) ConcatStrings(parts ...string) {
+	n := 0
+	for _, s := range parts {
+		n += len(s)
+	}
+	bb.Guarantee(n)
+	for _, s := range parts {
+		bb.B = append(bb.B, s...)
+	}
+}
+
+// Appendf formats according to a format specifier and appends the result to Buffer.B.
+func (bb *Buffer) Appendf(format string, a ...interface{}) {
+	_, _ = fmt.Fprintf(bb, format, a...)
+}
+
 // Set sets Buffer.B to p.
 func (bb *Buffer) Set(p []byte) {
-	bb.B = appendBytes(bb.B[:0], p...)
+	bb.B = bb.allocator().Append(bb.B[:0], p...)
 }
 
 // SetString sets Buffer.B to s.
 func (bb *Buffer) SetString(s string) {
-	bb.B = appendString(bb.B[:0], s)
+	bb.B = bb.allocator().AppendString(bb.B[:0], s)
 }
 
 // ReadFrom implements io.ReaderFrom.
@@ -195,9 +278,9 @@ func (bb *Buffer) ReadFrom(r io.Reader) (int64, error) {
 			if bCap > math.MaxInt32 {
 				bCap = math.MaxInt32
 			}
-			pNew := defaultPools.bs.New(bCap)
+			pNew := bb.allocator().New(bCap)
 			copy(pNew, p)
-			defaultPools.bs.Release(p)
+			bb.allocator().Release(p)
 			p = pNew
 		}
 		nn, err := r.Read(p[n:])
@@ -298,7 +381,11 @@ func (bb *Buffer) PutAll(r ...*bytes.Reader) {
 			readerpool.Release(r[i])
 		}
 	}
-	defaultPools.bs.Release(bb.B)
+	a := bb.allocator()
+	observeLifetime(a, cap(bb.B), bb.t)
+	a.Release(bb.B)
 	bb.B = nil
-	defaultPools.buf.Put(bb)
+	bb.a = nil
+	bb.t = 0
+	defaultPools.put(bb)
 }
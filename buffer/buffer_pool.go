@@ -2,29 +2,85 @@ package buffer
 
 import (
 	"bytes"
+	"io"
 	"sync"
+	"sync/atomic"
 
 	"github.com/fufuok/bytespool"
 	"github.com/fufuok/bytespool/readerpool"
 )
 
-var defaultPools = &pools{
-	bs: bytespool.DefaultCapacityPools,
+var defaultPools = &pools{}
+
+func init() {
+	_ = bytespool.RegisterFunc(bytespool.BufferPoolName, Pools)
 }
 
 // DefaultBufferSize is an initial allocation minimal capacity.
 var DefaultBufferSize = 64
 
 type pools struct {
-	bs  *bytespool.CapacityPools
-	buf sync.Pool
+	bs     atomic.Value             // *bytespool.CapacityPools, nil to use bytespool.Default()
+	bsMu   sync.Mutex               // Serializes the replacements of bs
+	owned  *bytespool.CapacityPools // The pools in bs created by SetCapacity, closed when replaced by it
+	buf    sync.Pool
+	shrink int64 // Capacity above which Reset shrinks the buffers, 0 to disable
+}
+
+// put puts bb back into the buffer pool, unless built with the bytespool_nopool tag.
+func (ps *pools) put(bb *Buffer) {
+	if !bytespool.NoPool {
+		ps.buf.Put(bb)
+	}
+}
+
+func (ps *pools) get() *bytespool.CapacityPools {
+	if p, _ := ps.bs.Load().(*bytespool.CapacityPools); p != nil {
+		return p
+	}
+	return bytespool.Default()
 }
 
 // SetCapacity initialize to the default byte slice pool.
 // Divide into multiple pools according to the capacity scale.
 // Maximum range of byte slice pool: [2,math.MaxInt32]
+// It is safe to call while buffers are in use: they are released to the pools they were acquired from,
+// and the pools previously created by SetCapacity are closed. The pools set by SetPools are not closed.
 func SetCapacity(minSize, maxSize int) {
-	defaultPools.bs = bytespool.NewCapacityPools(minSize, maxSize)
+	p := bytespool.NewCapacityPools(minSize, maxSize)
+	defaultPools.bsMu.Lock()
+	old := defaultPools.owned
+	defaultPools.owned = p
+	defaultPools.bs.Store(p)
+	defaultPools.bsMu.Unlock()
+	if old != nil {
+		old.Close()
+	}
+}
+
+// SetPools atomically replaces the byte slice pool used by the new buffers with p,
+// nil to use the default pools of bytespool (see bytespool.Default), which is the initial state.
+// It returns the pools previously set, nil if none, which are not closed,
+// even when they were created by SetCapacity.
+func SetPools(p *bytespool.CapacityPools) *bytespool.CapacityPools {
+	defaultPools.bsMu.Lock()
+	defer defaultPools.bsMu.Unlock()
+	old, _ := defaultPools.bs.Load().(*bytespool.CapacityPools)
+	defaultPools.bs.Store(p)
+	defaultPools.owned = nil
+	return old
+}
+
+// SetShrinkOnReset makes Buffer.Reset replace a byte slice of a capacity larger than capacity
+// with one of capacity, the large array is released, so that long-lived buffers do not pin large classes.
+// 0 disables it, which is the initial state.
+func SetShrinkOnReset(capacity int) {
+	atomic.StoreInt64(&defaultPools.shrink, int64(capacity))
+}
+
+// Pools returns the byte slice pool used by the new buffers.
+func Pools() *bytespool.CapacityPools {
+	return defaultPools.get()
 }
 
 // Clone returns a copy of the Buffer.B.
@@ -36,10 +92,10 @@ func Clone(bb *Buffer) *Buffer {
 }
 
 // Make return a Buffer with a byte slice of length 0.
-// Capacity will not be 0, max(capacity, defaultPools.bs.MinSize())
+// Capacity will not be 0, max(capacity, Pools().MinSize())
 func Make(capacity int) *Buffer {
 	bb := New(capacity)
-	bb.Reset()
+	bb.B = bb.B[:0]
 	return bb
 }
 
@@ -48,11 +104,11 @@ func Make64(capacity uint64) *Buffer {
 }
 
 func MakeMax() *Buffer {
-	return Make(defaultPools.bs.MaxSize())
+	return Make(defaultPools.get().MaxSize())
 }
 
 func MakeMin() *Buffer {
-	return Make(defaultPools.bs.MinSize())
+	return Make(defaultPools.get().MinSize())
 }
 
 func Get(capacity ...int) *Buffer {
@@ -67,16 +123,98 @@ func Get(capacity ...int) *Buffer {
 // Warning: may contain old data.
 // Warning: returned buf is never equal to nil
 func New(size int) *Buffer {
+	return NewFrom(defaultPools.get(), size)
+}
+
+// NewFrom is New with a byte slice acquired from a instead of Pools().
+// The Buffer grows and releases its byte slices through a.
+func NewFrom(a bytespool.Allocator, size int) *Buffer {
+	return newBuffer(a, a.New(size))
+}
+
+// MakeFrom is Make with a byte slice acquired from a instead of Pools().
+// The Buffer grows and releases its byte slices through a.
+func MakeFrom(a bytespool.Allocator, capacity int) *Buffer {
+	bb := NewFrom(a, capacity)
+	bb.B = bb.B[:0]
+	return bb
+}
+
+// ReadAll reads from r until EOF into a new Buffer, see bytespool.CapacityPools.ReadAll.
+// The data read is returned even on error, release the Buffer anyway.
+func ReadAll(r io.Reader) (*Buffer, error) {
+	return ReadAllWith(r, bytespool.ReadConfig{})
+}
+
+// ReadAllWith is ReadAll with a size hint and a maximum size, see bytespool.CapacityPools.ReadAllWith.
+func ReadAllWith(r io.Reader, cfg bytespool.ReadConfig) (*Buffer, error) {
+	p := defaultPools.get()
+	buf, err := p.ReadAllWith(r, cfg)
+	return newBuffer(p, buf), err
+}
+
+// ReadN reads exactly n bytes from r into a new Buffer, nil on error.
+func ReadN(r io.Reader, n int) (*Buffer, error) {
+	p := defaultPools.get()
+	buf, err := p.ReadN(r, n)
+	if err != nil {
+		return nil, err
+	}
+	return newBuffer(p, buf), nil
+}
+
+// ReadFile reads the named file into a new Buffer, nil if it cannot be opened.
+// The data read is returned even on error, release the Buffer anyway.
+func ReadFile(name string) (*Buffer, error) {
+	return ReadFileWith(name, bytespool.ReadConfig{})
+}
+
+// ReadFileWith is ReadFile with a maximum size.
+func ReadFileWith(name string, cfg bytespool.ReadConfig) (*Buffer, error) {
+	p := defaultPools.get()
+	buf, err := p.ReadFileWith(name, cfg)
+	if buf == nil {
+		return nil, err
+	}
+	return newBuffer(p, buf), err
+}
+
+// FromHandle returns a Buffer that takes ownership of the byte slice of h,
+// and grows and releases its byte slices through the pools h was acquired from.
+func FromHandle(h bytespool.Handle) *Buffer {
+	return newBuffer(h.Pools(), h.B)
+}
+
+func newBuffer(a bytespool.Allocator, buf []byte) *Buffer {
 	v := defaultPools.buf.Get()
 	if v != nil {
 		bb := v.(*Buffer)
-		bb.B = defaultPools.bs.New(size)
+		bb.B = buf
+		bb.a = a
+		bb.t = lifetimeStamp(a)
 		bb.RefReset()
 		return bb
 	}
 	return &Buffer{
-		B: defaultPools.bs.New(size),
+		B: buf,
 		c: 0,
+		a: a,
+		t: lifetimeStamp(a),
+	}
+}
+
+// lifetimeStamp returns the acquisition stamp for the lifetime statistics of a,
+// 0 if a does not record them.
+func lifetimeStamp(a bytespool.Allocator) int64 {
+	if p, ok := a.(*bytespool.CapacityPools); ok {
+		return p.LifetimeStamp()
+	}
+	return 0
+}
+
+func observeLifetime(a bytespool.Allocator, capacity int, stamp int64) {
+	if p, ok := a.(*bytespool.CapacityPools); ok {
+		p.ObserveLifetime(capacity, stamp)
 	}
 }
 
@@ -87,14 +225,7 @@ func New(size int) *Buffer {
 // prepare a Buffer to read existing data. It can also be used to set
 // the initial size of the internal buffer for writing.
 func NewBuffer(buf []byte) *Buffer {
-	v := defaultPools.buf.Get()
-	if v != nil {
-		bb := v.(*Buffer)
-		bb.B = buf
-		bb.RefReset()
-		return bb
-	}
-	return &Buffer{B: buf}
+	return newBuffer(defaultPools.get(), buf)
 }
 
 // NewBytes returns a byte slice of the specified content.
@@ -115,11 +246,11 @@ func NewString(s string) *Buffer {
 // If there is insufficient capacity,
 // a new underlying array is allocated and the old array is reclaimed.
 func appendBytes(buf []byte, elems ...byte) []byte {
-	return defaultPools.bs.Append(buf, elems...)
+	return defaultPools.get().Append(buf, elems...)
 }
 
 func appendString(buf []byte, elems string) []byte {
-	return defaultPools.bs.AppendString(buf, elems)
+	return defaultPools.get().AppendString(buf, elems)
 }
 
 // Release put B back into the byte pool of the corresponding scale,
@@ -127,9 +258,13 @@ func appendString(buf []byte, elems string) []byte {
 // Buffers smaller than the minimum capacity or larger than the maximum capacity are discarded.
 func Release(bb *Buffer) (ok bool) {
 	if bb.RefSwapDec() == 0 {
-		ok = defaultPools.bs.Release(bb.B)
+		a := bb.allocator()
+		observeLifetime(a, cap(bb.B), bb.t)
+		ok = a.Release(bb.B)
 		bb.B = nil
-		defaultPools.buf.Put(bb)
+		bb.a = nil
+		bb.t = 0
+		defaultPools.put(bb)
 	}
 	return
 }
@@ -140,11 +275,11 @@ func Put(bb *Buffer) {
 }
 
 func MinSize() int {
-	return defaultPools.bs.MinSize()
+	return defaultPools.get().MinSize()
 }
 
 func MaxSize() int {
-	return defaultPools.bs.MaxSize()
+	return defaultPools.get().MaxSize()
 }
 
 // GetReader returns an io.Reader from bs.
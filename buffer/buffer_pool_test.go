@@ -2,7 +2,12 @@ package buffer
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/fufuok/bytespool"
@@ -64,7 +69,7 @@ func TestBufferPool_Boundary(t *testing.T) {
 	}
 
 	SetCapacity(0, 7)
-	defer SetCapacity(bytespool.DefaultCapacityPools.MinSize(), bytespool.DefaultCapacityPools.MaxSize())
+	defer SetPools(nil)
 	if MinSize() != 2 || MaxSize() != 7 {
 		t.Fatalf("expect minSize is 2, maxSize is 7, but got: %d, %d", MinSize(), MaxSize())
 	}
@@ -97,3 +102,183 @@ func TestBufferPool_Boundary(t *testing.T) {
 		t.Fatal("expect to release the buffer successfully, but not")
 	}
 }
+
+func TestSetPools(t *testing.T) {
+	defer SetPools(nil)
+	if SetPools(nil) != nil || Pools() != bytespool.Default() {
+		t.Fatal("expect the buffers use the default pools")
+	}
+
+	p := bytespool.NewCapacityPools(2, 128)
+	p.SetWithStats(true)
+	SetPools(p)
+	bb := Get(10)
+	_, _ = bb.WriteString("0123456789abcdefghij")
+
+	// released to the pools it was acquired from
+	SetPools(bytespool.NewCapacityPools(2, 256))
+	if Pools() == p || p.Closed() {
+		t.Fatal("expect the pools are replaced but not closed")
+	}
+	bb.Release()
+	sum := bytespool.RuntimeStatsSummary(0, p)
+	if sum.ReleasedCount != 2 || sum.Outstanding != 0 {
+		t.Fatalf("expect the byte slices are released to the origin pools, but got: %+v", sum)
+	}
+
+	// the pools created by SetCapacity are closed when replaced by SetCapacity
+	SetCapacity(2, 512)
+	q := Pools()
+	SetCapacity(2, 1024)
+	if !q.Closed() {
+		t.Fatal("expect the previous pools are closed")
+	}
+
+	// the pools set by SetPools are never closed by SetCapacity
+	SetPools(bytespool.Default())
+	SetCapacity(2, 2048)
+	if bytespool.Default().Closed() {
+		t.Fatal("expect the default pools are not closed")
+	}
+	q = Pools()
+	if SetPools(nil) != q || q.Closed() {
+		t.Fatal("expect the pools returned by SetPools are not closed")
+	}
+	SetCapacity(2, 4096)
+	if q.Closed() {
+		t.Fatal("expect the pools handed out by SetPools are not closed by SetCapacity")
+	}
+}
+
+func TestNewFrom(t *testing.T) {
+	p := bytespool.NewCapacityPools(2, 128)
+	p.SetWithStats(true)
+
+	bb := MakeFrom(p, 10)
+	if bb.Len() != 0 || bb.Cap() != 16 || bb.Allocator() != p {
+		t.Fatal("buffer initial error")
+	}
+	// grows through the origin pools
+	_, _ = bb.WriteString("0123456789abcdefghij")
+	_ = bb.WriteByte('k')
+	_, _ = bb.Write([]byte("lmn"))
+	bb.Guarantee(64)
+	bb.Release()
+	if sum := bytespool.RuntimeStatsSummary(0, p); sum.Outstanding != 0 || sum.ReleasedCount != 3 {
+		t.Fatalf("expect the byte slices are released to the origin pools, but got: %+v", sum)
+	}
+
+	h := p.MakeHandle(8)
+	bb = FromHandle(h)
+	if bb.Allocator() != p || bb.Cap() != 8 {
+		t.Fatal("expect the buffer takes the handle")
+	}
+	if bb.Pools() != p || MakeFrom(bytespool.HeapAllocator{}, 8).Pools() != nil {
+		t.Fatal("expect Pools returns the pools of the buffer")
+	}
+	bb.Release()
+
+	bb = NewFrom(p, 4)
+	if bb.Len() != 4 || bb.Allocator() != p {
+		t.Fatal("buffer initial error")
+	}
+	bb.Release()
+	if Get().Allocator() != Pools() {
+		t.Fatal("expect the buffer uses the current pools")
+	}
+}
+
+func TestNewFrom_Allocator(t *testing.T) {
+	d := bytespool.NewDebugAllocator(bytespool.HeapAllocator{})
+	bb := MakeFrom(d, 4)
+	_, _ = bb.WriteString("0123456789")
+	bb.Guarantee(100)
+	if _, err := bb.ReadFrom(strings.NewReader(strings.Repeat("x", 1000))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := len(d.Outstanding()); n != 1 {
+		t.Fatalf("expect 1 outstanding byte slice, but got %d", n)
+	}
+	bb.Release()
+	if n := len(d.Outstanding()); n != 0 {
+		t.Fatalf("expect no outstanding byte slices, but got %d", n)
+	}
+}
+
+func TestReadAll(t *testing.T) {
+	data := strings.Repeat("0123456789", 100)
+	bb, err := ReadAll(strings.NewReader(data))
+	if err != nil || bb.String() != data || bb.Cap() != 1024 {
+		t.Fatalf("expect the data in cap 1024, but got cap %d, %v", bb.Cap(), err)
+	}
+	bb.Release()
+
+	bb, err = ReadAllWith(strings.NewReader(data), bytespool.ReadConfig{MaxSize: 100})
+	if err != bytespool.ErrReadTooLarge || bb.Len() != 100 {
+		t.Fatalf("expect ErrReadTooLarge, but got %d, %v", bb.Len(), err)
+	}
+	bb.Release()
+
+	if bb, err = ReadN(strings.NewReader(data), 4); err != nil || bb.String() != "0123" {
+		t.Fatalf("expect 0123, but got %v", err)
+	}
+	bb.Release()
+	if bb, err = ReadN(strings.NewReader("ab"), 4); err != io.ErrUnexpectedEOF || bb != nil {
+		t.Fatalf("expect io.ErrUnexpectedEOF, but got %v", err)
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "buffer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "data")
+	if err = ioutil.WriteFile(name, []byte(testString), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	bb, err := ReadFile(name)
+	if err != nil || bb.String() != testString {
+		t.Fatalf("expect the file content, but got %v", err)
+	}
+	bb.Release()
+	if bb, err = ReadFileWith(name, bytespool.ReadConfig{MaxSize: 4}); err != bytespool.ErrReadTooLarge || bb.Len() != 4 {
+		t.Fatalf("expect ErrReadTooLarge, but got %v", err)
+	}
+	bb.Release()
+	if bb, err = ReadFile(filepath.Join(dir, "missing")); !os.IsNotExist(err) || bb != nil {
+		t.Fatalf("expect a not exist error, but got %v", err)
+	}
+}
+
+func TestBuffer_DefaultPoolsSwap(t *testing.T) {
+	old := bytespool.Default()
+	defer bytespool.SetDefault(old)
+
+	prev := bytespool.NewCapacityPools(2, 1024)
+	prev.SetWithStats(true)
+	bytespool.SetDefault(prev)
+	bb := Get(100)
+	if Pools() != prev || bb.Allocator() != prev {
+		t.Fatal("expect the buffers follow the default pools")
+	}
+	_, _ = bb.WriteString(testString)
+
+	// the previous pools are closed while bb still holds a byte slice from them
+	bytespool.InitDefaultPools(2, 2048)
+	if Pools() == prev || !prev.Closed() {
+		t.Fatal("expect the previous pools are replaced and closed")
+	}
+	if bb.String() != testString {
+		t.Fatal("expect the held data is still valid")
+	}
+	if bb.Release() {
+		t.Fatal("expect the byte slice is discarded by the closed pools")
+	}
+	sum := bytespool.RuntimeStatsSummary(0, prev)
+	if sum.ReleasedCount != 1 || sum.DiscardCount != 1 || sum.Outstanding != 0 {
+		t.Fatalf("expect the byte slice is released to its origin pools, but got: %+v", sum)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"io"
 	"testing"
+
+	"github.com/fufuok/bytespool"
 )
 
 var (
@@ -289,3 +291,64 @@ func TestBuffer_WriteTo(t *testing.T) {
 		buf.Reset()
 	}
 }
+
+func TestBuffer_Compact(t *testing.T) {
+	p := bytespool.NewCapacityPools(2, 1024)
+	p.SetWithStats(true)
+	bb := MakeFrom(p, 1000)
+	bb.SetString(testString)
+	bb.Compact()
+	if bb.String() != testString || bb.Cap() != 32 {
+		t.Fatalf("expect the data is moved to cap 32, but got cap %d", bb.Cap())
+	}
+	bb.Compact()
+	if bb.Cap() != 32 {
+		t.Fatal("expect no move")
+	}
+	bb.Release()
+	if sum := bytespool.RuntimeStatsSummary(0, p); sum.Outstanding != 0 || sum.ReleasedCount != 2 {
+		t.Fatalf("expect the large array is released, but got: %+v", sum)
+	}
+
+	// not supported by the allocator
+	bb = MakeFrom(bytespool.HeapAllocator{}, 1000)
+	bb.Compact()
+	if bb.Cap() != 1000 {
+		t.Fatal("expect no move")
+	}
+}
+
+func TestSetShrinkOnReset(t *testing.T) {
+	defer SetShrinkOnReset(0)
+	bb := Make(1000)
+	bb.Reset()
+	if bb.Cap() != 1024 {
+		t.Fatalf("expect no shrink by default, but got cap %d", bb.Cap())
+	}
+	SetShrinkOnReset(128)
+	if bb = Make(1000); bb.Cap() != 1024 {
+		t.Fatalf("expect Make is not shrunk, but got cap %d", bb.Cap())
+	}
+	bb.SetString(testString)
+	bb.Reset()
+	if bb.Len() != 0 || bb.Cap() != 128 {
+		t.Fatalf("expect cap 128, but got %d", bb.Cap())
+	}
+	bb.Reset()
+	if bb.Cap() != 128 {
+		t.Fatal("expect no move")
+	}
+	bb.Release()
+}
+
+func TestBuffer_JoinAndFormat(t *testing.T) {
+	bb := Make(4)
+	bb.Join([]byte(", "), []byte("a"), []byte("bc"))
+	bb.Join([]byte(","))
+	bb.ConcatStrings(";", "x", "yz")
+	bb.Appendf(" %s=%d", "k", 42)
+	if bb.String() != "a, bc;xyz k=42" {
+		t.Fatalf("unexpected result %q", bb.String())
+	}
+	bb.Release()
+}
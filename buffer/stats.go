@@ -5,17 +5,34 @@ import (
 )
 
 func SetWithStats(t bool) {
-	defaultPools.bs.SetWithStats(t)
+	defaultPools.get().SetWithStats(t)
 }
 
 func GetWithStats() bool {
-	return defaultPools.bs.GetWithStats()
+	return defaultPools.get().GetWithStats()
 }
 
 func RuntimeStats() map[string]uint64 {
-	return bytespool.RuntimeStats(defaultPools.bs)
+	return bytespool.RuntimeStats(defaultPools.get())
 }
 
 func RuntimeStatsSummary(topN int) bytespool.RuntimeSummary {
-	return bytespool.RuntimeStatsSummary(topN, defaultPools.bs)
+	return bytespool.RuntimeStatsSummary(topN, defaultPools.get())
+}
+
+func RuntimeStatsSummaryBy(topN int, by bytespool.SortBy) bytespool.RuntimeSummary {
+	return bytespool.RuntimeStatsSummaryBy(topN, by, defaultPools.get())
+}
+
+// SetWithLifetime enables or disables recording how long buffers are held, from Get/Make/New to Release.
+func SetWithLifetime(t bool) {
+	defaultPools.get().SetWithLifetime(t)
+}
+
+func GetWithLifetime() bool {
+	return defaultPools.get().GetWithLifetime()
+}
+
+func LifetimeStats() []bytespool.LifetimeStat {
+	return bytespool.LifetimeStats(defaultPools.get())
 }
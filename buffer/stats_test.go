@@ -3,13 +3,17 @@ package buffer
 import (
 	"runtime/debug"
 	"testing"
+	"time"
 
 	"github.com/fufuok/bytespool"
 )
 
 func TestRuntimeStats(t *testing.T) {
+	if bytespool.NoPool {
+		t.Skip("byte slices are not reused with the bytespool_nopool tag")
+	}
 	defer func() {
-		defaultPools.bs = bytespool.DefaultCapacityPools
+		SetPools(nil)
 	}()
 
 	var n, b, r uint64
@@ -43,3 +47,30 @@ func TestRuntimeStats(t *testing.T) {
 		t.Fatalf("expect reusedBytes is %d, but got %d", n, stats["ReusedBytes"])
 	}
 }
+
+func TestLifetimeStats(t *testing.T) {
+	defer func() {
+		SetPools(nil)
+	}()
+	SetCapacity(2, 128)
+	SetWithLifetime(true)
+	if !GetWithLifetime() {
+		t.Fatal("expect lifetime recording is enabled")
+	}
+
+	bb := Get(10)
+	bb.RefInc()
+	time.Sleep(time.Millisecond)
+	bb.Release()
+	if LifetimeStats() != nil {
+		t.Fatal("expect no lifetime until the last reference is released")
+	}
+	bb.Release()
+	bb = NewBuffer(make([]byte, 0, 64))
+	bb.PutAll()
+
+	stats := LifetimeStats()
+	if len(stats) != 2 || stats[0].Capacity != 16 || stats[0].Max < time.Millisecond || stats[1].Capacity != 64 {
+		t.Fatalf("unexpected lifetime stats: %+v", stats)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !purego
+// +build !purego
+
+package buffer
+
+import (
+	"unsafe"
+)
+
+// UnsafeString not immutable.
+func (bb *Buffer) UnsafeString() string {
+	return *(*string)(unsafe.Pointer(&bb.B))
+}
@@ -0,0 +1,10 @@
+//go:build purego
+// +build purego
+
+package buffer
+
+// UnsafeString not immutable.
+// With the purego tag, it is a copy like String.
+func (bb *Buffer) UnsafeString() string {
+	return string(bb.B)
+}
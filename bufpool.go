@@ -18,3 +18,8 @@ func (b *BufPool) Get() []byte {
 func (b *BufPool) Put(buf []byte) {
 	b.pool.Put(buf)
 }
+
+// Pools returns the underlying pools, e.g. to Register them.
+func (b *BufPool) Pools() *CapacityPools {
+	return b.pool
+}
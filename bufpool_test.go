@@ -3,6 +3,7 @@ package bytespool
 import "testing"
 
 func TestBufPool(t *testing.T) {
+	skipNoPool(t)
 	size := 1024
 	bufPool := NewBufPool(size)
 	buf := bufPool.Get()
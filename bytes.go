@@ -12,6 +12,9 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+//go:build !purego && !bytespool_nolinkname
+// +build !purego,!bytespool_nolinkname
+
 package bytespool
 
 import (
@@ -24,16 +27,12 @@ type slice struct {
 	cap  int
 }
 
-type bytesHeader struct {
-	Data *byte
-	Len  int
-	Cap  int
-}
-
 //go:linkname mallocgc runtime.mallocgc
 func mallocgc(size uintptr, typ unsafe.Pointer, needzero bool) unsafe.Pointer
 
 // Bytes allocates a byte slice but does not clean up the memory it references.
+// If runtime.mallocgc cannot be linked by the toolchain, build with the bytespool_nolinkname tag
+// (or purego), then Bytes falls back to make.
 // Throw a fatal error instead of panic if cap is greater than runtime.maxAlloc.
 // NOTE: MUST set any byte element before it's read.
 // Ref: xiaost/bytedance-gopkg
@@ -1,3 +1,6 @@
+//go:build !bytespool_nopool
+// +build !bytespool_nopool
+
 package bytespool_test
 
 import (
@@ -0,0 +1,14 @@
+//go:build purego || bytespool_nolinkname
+// +build purego bytespool_nolinkname
+
+package bytespool
+
+// Bytes allocates a byte slice.
+// This is the fallback used with the purego or bytespool_nolinkname tags,
+// when runtime.mallocgc cannot (or should not) be linked: the memory is zeroed by make.
+func Bytes(len, cap int) []byte {
+	if len < 0 || len > cap {
+		panic("dirtmake.Bytes: len out of range")
+	}
+	return make([]byte, len, cap)
+}
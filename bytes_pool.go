@@ -6,7 +6,6 @@ import (
 	"sort"
 	"sync"
 	"sync/atomic"
-	"unsafe"
 )
 
 const (
@@ -15,50 +14,102 @@ const (
 	defaultMaxSize = 4 * 1024 * 1024 // 4 MiB
 )
 
+// DefaultCapacityPools is the default pools.
+//
+// Deprecated: Use Default. The variable is still updated by InitDefaultPools and SetDefault,
+// but reading it while the default pools are being replaced is a data race.
 var DefaultCapacityPools = NewCapacityPools(defaultMinSize, defaultMaxSize)
 
+var (
+	defaultPools   atomic.Value // *CapacityPools
+	defaultPoolsMu sync.Mutex   // Serializes the replacements of the default pools
+)
+
+func init() {
+	defaultPools.Store(DefaultCapacityPools)
+}
+
 type CapacityPools struct {
-	pools       []*bytesPool
-	minSize     int
-	maxSize     int
-	maxIndex    int
-	decIndex    int
-	newBytes    uint64 // New bytes allocated for pools
-	outBytes    uint64 // Bytes allocated outside pools
-	outCount    uint64 // Number of bytes allocated outside pools
-	reusedBytes uint64 // Bytes reused from pools
-	withStats   bool   // Controls whether to collect statistics for this pool
+	pools        []*bytesPool
+	minSize      int
+	maxSize      int
+	maxIndex     int
+	decIndex     int
+	newBytes     uint64    // New bytes allocated for pools
+	newCount     uint64    // Number of byte slices newly allocated for pools
+	outBytes     uint64    // Bytes allocated outside pools
+	outCount     uint64    // Number of bytes allocated outside pools
+	reusedBytes  uint64    // Bytes reused from pools
+	discardCount uint64    // Number of byte slices discarded by Release
+	flags        uint32    // flagClosed and flagPressure, the releases are discarded when any is set
+	trimmedBytes uint64    // Bytes of the cached byte slices freed by Trim
+	trimmedCount uint64    // Number of cached byte slices freed by Trim
+	trackIdle    uint32    // Set while the janitor is running, the classes record their last use
+	withStats    bool      // Controls whether to collect statistics for this pool
+	hooks        Hooks     // Optional event hooks, nil when unset
+	backend      Backend   // Source of fresh byte slices, nil for Bytes (mallocgc)
+	manual       bool      // The backend memory is not managed by the GC, see bytesPool.free
+	overflow     *overflow // Optional cache of the byte slices larger than maxSize, nil when disabled
+	shrinkRatio  float64   // Ratio of length to capacity below which Shrink moves a byte slice
+
+	withLifetime bool           // Controls whether to record hold durations
+	lifetimes    []lifetimeHist // Hold durations per pool, allocated by SetWithLifetime
+
+	tags sync.Map // Tagged views of this pool, tag => *TaggedPool
+
+	mu       sync.Mutex     // Guards the optional background workers below
+	sampler  *sampler       // Periodic snapshots for rate statistics
+	janitor  *janitor       // Periodic trimming of the idle classes
+	pressure *pressureWatch // Periodic memory pressure checks
 }
 
 // bytesPool represents a pool for a specific capacity
 type bytesPool struct {
-	pool      sync.Pool
-	capacity  int
-	reuseHits uint64 // Number of times byte slices were reused from this pool
+	pool     sync.Pool
+	capacity int
+	classCounters
+	lastUsed int64 // Monotonic time of the last use, only recorded while the janitor is running
+
+	// GC-resistant cache used instead of pool when the backend memory is not managed by the GC,
+	// otherwise it would leak when sync.Pool drops it.
+	mu   sync.Mutex
+	free []item
 }
 
 // InitDefaultPools initialize to the default pool.
+// The previous default pools are closed, see SetDefault.
 func InitDefaultPools(minSize, maxSize int) {
-	DefaultCapacityPools = NewCapacityPools(minSize, maxSize)
+	SetDefault(NewCapacityPools(minSize, maxSize)).Close()
+}
+
+// Default returns the default pools used by the package-level functions.
+func Default() *CapacityPools {
+	return defaultPools.Load().(*CapacityPools)
+}
+
+// SetDefault atomically replaces the default pools with p and returns the previous ones.
+// It is safe to call while the default pools are in use.
+//
+// The previous pools are not closed: byte slices acquired from them should be released to them,
+// (e.g. buffer.Buffer releases to the pools it was acquired from), then call Close on them to retire them.
+// Byte slices released with the package-level functions go to the current default pools.
+func SetDefault(p *CapacityPools) *CapacityPools {
+	if p == nil {
+		panic("bytespool: nil default pools")
+	}
+	defaultPoolsMu.Lock()
+	defer defaultPoolsMu.Unlock()
+	old := Default()
+	defaultPools.Store(p)
+	DefaultCapacityPools = p
+	return old
 }
 
 // NewCapacityPools divide into multiple pools according to the capacity scale.
 // Maximum range of byte slice pool: [minCapacity,math.MaxInt32]
 func NewCapacityPools(minSize, maxSize int) *CapacityPools {
 	var pools []*bytesPool
-	if maxSize > math.MaxInt32 {
-		maxSize = math.MaxInt32
-	}
-	if maxSize < minCapacity {
-		maxSize = minCapacity
-	}
-	if minSize > maxSize {
-		minSize = maxSize
-	}
-	if minSize < minCapacity {
-		minSize = minCapacity
-	}
-
+	minSize, maxSize = clampSizes(minSize, maxSize)
 	mn := getIndex(minSize)
 	mx := getIndex(maxSize)
 	for i := mn; i <= mx; i++ {
@@ -75,10 +126,91 @@ func NewCapacityPools(minSize, maxSize int) *CapacityPools {
 	}
 }
 
+// clampSizes returns the size range of the pools within [minCapacity,math.MaxInt32].
+func clampSizes(minSize, maxSize int) (int, int) {
+	if maxSize > math.MaxInt32 {
+		maxSize = math.MaxInt32
+	}
+	if maxSize < minCapacity {
+		maxSize = minCapacity
+	}
+	if minSize > maxSize {
+		minSize = maxSize
+	}
+	if minSize < minCapacity {
+		minSize = minCapacity
+	}
+	return minSize, maxSize
+}
+
 func newBytesPool(size int) *bytesPool {
 	return &bytesPool{capacity: size}
 }
 
+// classCounters are the statistics of a pool of a specific capacity, shared by CapacityPools and SlicePool.
+type classCounters struct {
+	reuseHits uint64 // Number of times the slices were reused from this pool
+	misses    uint64 // Number of times the slices were newly allocated for this pool
+	reqSize   uint64 // Sum of the sizes requested from this pool
+	releases  uint64 // Number of slices put back into this pool
+}
+
+// stat returns the statistics of a pool of capacity, without rank.
+// The bytes are counted in units of unit bytes, the size of the elements.
+func (cc *classCounters) stat(capacity int, unit uint64) PoolStat {
+	st := PoolStat{
+		Capacity:  capacity,
+		ReuseHits: atomic.LoadUint64(&cc.reuseHits),
+		Misses:    atomic.LoadUint64(&cc.misses),
+		Releases:  atomic.LoadUint64(&cc.releases),
+	}
+	gets := st.ReuseHits + st.Misses
+	st.Outstanding = outstanding(gets, st.Releases)
+	st.Bytes = gets * uint64(capacity) * unit
+	if req := atomic.LoadUint64(&cc.reqSize) * unit; req < st.Bytes {
+		st.Waste = st.Bytes - req
+	}
+	if gets > 0 {
+		st.ReuseRatio = float64(st.ReuseHits) / float64(gets)
+		st.MissRatio = float64(st.Misses) / float64(gets)
+	}
+	return st
+}
+
+// get returns a cached item, nil if none.
+func (bp *bytesPool) get(manual bool) item {
+	if NoPool {
+		return nil
+	}
+	if !manual {
+		it, _ := bp.pool.Get().(item)
+		return it
+	}
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	n := len(bp.free)
+	if n == 0 {
+		return nil
+	}
+	it := bp.free[n-1]
+	bp.free[n-1] = nil
+	bp.free = bp.free[:n-1]
+	return it
+}
+
+func (bp *bytesPool) put(it item, manual bool) {
+	if NoPool {
+		return
+	}
+	if !manual {
+		bp.pool.Put(it)
+		return
+	}
+	bp.mu.Lock()
+	bp.free = append(bp.free, it)
+	bp.mu.Unlock()
+}
+
 // Clone return a copy of the byte slice
 func (p *CapacityPools) Clone(buf []byte) []byte {
 	return p.NewBytes(buf)
@@ -111,19 +243,37 @@ func (p *CapacityPools) New(size int) (buf []byte) {
 
 	bp := p.getMakePool(size)
 	if bp == nil {
+		if p.overflow != nil && size > p.maxSize {
+			if buf = p.newOverflow(size); buf != nil {
+				return buf
+			}
+		}
 		if p.withStats {
 			atomic.AddUint64(&p.outCount, 1)
 			atomic.AddUint64(&p.outBytes, uint64(size))
 		}
-		return Bytes(size, size)
+		if p.hooks != nil {
+			p.hooks.OnOutOfRange(size)
+		}
+		return p.alloc(size, size)
 	}
 
-	ptr, _ := bp.pool.Get().(*byte)
-	if ptr == nil {
+	if p.withStats {
+		atomic.AddUint64(&bp.reqSize, uint64(size))
+	}
+
+	p.touch(bp)
+	it := bp.get(p.manual)
+	if it == nil {
 		if p.withStats {
+			atomic.AddUint64(&bp.misses, 1)
+			atomic.AddUint64(&p.newCount, 1)
 			atomic.AddUint64(&p.newBytes, uint64(bp.capacity))
 		}
-		return Bytes(size, bp.capacity)
+		if p.hooks != nil {
+			p.hooks.OnMiss(bp.capacity, size)
+		}
+		return p.alloc(size, bp.capacity)
 	}
 
 	if p.withStats {
@@ -131,15 +281,11 @@ func (p *CapacityPools) New(size int) (buf []byte) {
 		atomic.AddUint64(&bp.reuseHits, 1)
 		atomic.AddUint64(&p.reusedBytes, uint64(bp.capacity))
 	}
+	if p.hooks != nil {
+		p.hooks.OnReuse(bp.capacity, size)
+	}
 
-	// go1.20
-	// return unsafe.Slice(ptr, bp.capacity)[:size]
-
-	sh := (*bytesHeader)(unsafe.Pointer(&buf))
-	sh.Data = ptr
-	sh.Len = size
-	sh.Cap = bp.capacity
-	return
+	return fromItem(it, size, bp.capacity)
 }
 
 func (p *CapacityPools) Get(size int) []byte {
@@ -173,12 +319,13 @@ func (p *CapacityPools) NewString(s string) []byte {
 // Append similar to the built-in function to append elements to the end of a slice.
 // If there is insufficient capacity,
 // a new underlying array is allocated and the old array is reclaimed.
+// Beyond the maximum capacity, the built-in append is used, unless the backend memory is not managed by the GC
+// (see Backend.Managed), then the old array is freed by the backend.
 func (p *CapacityPools) Append(buf []byte, elems ...byte) []byte {
 	n := len(buf)
-	c := cap(buf)
 	m := n + len(elems)
-	if c < m && c <= p.maxSize {
-		bbuf := p.New(m)
+	if size, ok := p.appendSize(cap(buf), m); ok {
+		bbuf := p.New(size)[:m]
 		copy(bbuf, buf)
 		copy(bbuf[n:], elems)
 		p.Release(buf)
@@ -189,10 +336,9 @@ func (p *CapacityPools) Append(buf []byte, elems ...byte) []byte {
 
 func (p *CapacityPools) AppendString(buf []byte, elems string) []byte {
 	n := len(buf)
-	c := cap(buf)
 	m := n + len(elems)
-	if c < m && c <= p.maxSize {
-		bbuf := p.New(m)
+	if size, ok := p.appendSize(cap(buf), m); ok {
+		bbuf := p.New(size)[:m]
 		copy(bbuf, buf)
 		copy(bbuf[n:], elems)
 		p.Release(buf)
@@ -201,19 +347,43 @@ func (p *CapacityPools) AppendString(buf []byte, elems string) []byte {
 	return append(buf, elems...)
 }
 
+// appendSize returns the size to allocate from the pools to grow a byte slice of capacity c to length m,
+// false to use the built-in append, which grows geometrically above the maximum capacity.
+// The byte slices of a manual backend are also doubled above the maximum capacity,
+// each of them costs an allocation of the backend.
+func (p *CapacityPools) appendSize(c, m int) (int, bool) {
+	if c >= m || c > p.maxSize && !p.manual {
+		return 0, false
+	}
+	if m > p.maxSize {
+		m = growCap(c, m)
+	}
+	return m, true
+}
+
 // Release put it back into the byte pool of the corresponding scale.
 // Buffers smaller than the minimum capacity or larger than the maximum capacity are discarded.
 func (p *CapacityPools) Release(buf []byte) bool {
 	bp := p.getReleasePool(cap(buf))
 	if bp == nil {
+		if p.overflow != nil && cap(buf) > p.maxSize {
+			return p.releaseOverflow(buf)
+		}
+		p.discard(buf, DiscardOutOfRange)
 		return false
 	}
 
-	// go1.20, store array pointer,
-	// bp.pool.Put(unsafe.SliceData(buf))
+	if p.withStats {
+		atomic.AddUint64(&bp.releases, 1)
+	}
 
-	sh := (*bytesHeader)(unsafe.Pointer(&buf))
-	bp.pool.Put(sh.Data)
+	if flags := atomic.LoadUint32(&p.flags); flags != 0 {
+		p.discard(buf, flagReason(flags))
+		return false
+	}
+
+	p.touch(bp)
+	bp.put(toItem(buf), p.manual)
 	return true
 }
 
@@ -221,6 +391,101 @@ func (p *CapacityPools) Put(buf []byte) {
 	p.Release(buf)
 }
 
+// discard drops a byte slice rejected by Release, handing it back to the backend.
+// Byte slices without capacity, e.g. Release(nil), are ignored.
+func (p *CapacityPools) discard(buf []byte, reason DiscardReason) {
+	if cap(buf) == 0 {
+		return
+	}
+	if p.withStats {
+		atomic.AddUint64(&p.discardCount, 1)
+	}
+	if p.hooks != nil {
+		p.hooks.OnDiscard(cap(buf), reason)
+	}
+	if !NoPool && p.backend != nil {
+		p.backend.Free(buf[:cap(buf)])
+	}
+}
+
+// Close retires the pools: the cached byte slices are dropped and the later releases are discarded,
+// so the memory of the pools is collected as the byte slices acquired from them are released.
+// The pools can still hand out new byte slices. Close is idempotent.
+func (p *CapacityPools) Close() {
+	if p.setFlag(flagClosed, true) {
+		p.Drain()
+	}
+}
+
+// Drain immediately frees the cached byte slices of every class and of the overflow tier,
+// e.g. to avoid running out of memory.
+// It returns the number of bytes freed, which are counted as trimmed.
+func (p *CapacityPools) Drain() int {
+	drained := 0
+	for _, bp := range p.pools {
+		for p.drainOne(bp) {
+			drained += bp.capacity
+			atomic.AddUint64(&p.trimmedCount, 1)
+			atomic.AddUint64(&p.trimmedBytes, uint64(bp.capacity))
+		}
+	}
+	if p.overflow != nil {
+		n, bytes := p.drainOverflow()
+		drained += bytes
+		atomic.AddUint64(&p.trimmedCount, uint64(n))
+		atomic.AddUint64(&p.trimmedBytes, uint64(bytes))
+	}
+	return drained
+}
+
+// drainOne drops a cached byte slice of the class, handing it back to the backend.
+// It reports false if the class is empty.
+func (p *CapacityPools) drainOne(bp *bytesPool) bool {
+	it := bp.get(p.manual)
+	if it == nil {
+		return false
+	}
+	if p.backend != nil {
+		p.backend.Free(fromItem(it, bp.capacity, bp.capacity))
+	}
+	return true
+}
+
+// Closed reports whether Close has been called.
+func (p *CapacityPools) Closed() bool {
+	return atomic.LoadUint32(&p.flags)&flagClosed != 0
+}
+
+const (
+	flagClosed   uint32 = 1 << iota // Set by Close
+	flagPressure                    // Set while the memory is under pressure, see StartPressureWatch
+)
+
+// flagReason returns the reason of the releases discarded while flags are set.
+func flagReason(flags uint32) DiscardReason {
+	if flags&flagClosed != 0 {
+		return DiscardClosed
+	}
+	return DiscardPressure
+}
+
+// setFlag sets or clears flag, it reports whether the flag changed.
+func (p *CapacityPools) setFlag(flag uint32, on bool) bool {
+	for {
+		old := atomic.LoadUint32(&p.flags)
+		v := old &^ flag
+		if on {
+			v = old | flag
+		}
+		if v == old {
+			return false
+		}
+		if atomic.CompareAndSwapUint32(&p.flags, old, v) {
+			return true
+		}
+	}
+}
+
 func (p *CapacityPools) MinSize() int {
 	return p.minSize
 }
@@ -229,6 +494,11 @@ func (p *CapacityPools) MaxSize() int {
 	return p.maxSize
 }
 
+// Classes returns the number of capacity scales of the pool.
+func (p *CapacityPools) Classes() int {
+	return len(p.pools)
+}
+
 // SetWithStats enables or disables statistics collection for this pool.
 // When enabled, statistics will be collected, but this may affect performance.
 // When disabled (default), all atomic operations for statistics are skipped for better performance.
@@ -249,6 +519,11 @@ func (p *CapacityPools) getTotalNewBytes() uint64 {
 	return atomic.LoadUint64(&p.newBytes)
 }
 
+// getNewCount returns the number of byte slices newly allocated for pools
+func (p *CapacityPools) getNewCount() uint64 {
+	return atomic.LoadUint64(&p.newCount)
+}
+
 // getTotalOutBytes returns the sum of bytes allocated outside pools
 func (p *CapacityPools) getTotalOutBytes() uint64 {
 	return atomic.LoadUint64(&p.outBytes)
@@ -264,44 +539,89 @@ func (p *CapacityPools) getTotalReusedBytes() uint64 {
 	return atomic.LoadUint64(&p.reusedBytes)
 }
 
+// getReusedCount returns the number of times byte slices were reused from pools, including the overflow tier
+func (p *CapacityPools) getReusedCount() (n uint64) {
+	for _, bp := range p.pools {
+		n += atomic.LoadUint64(&bp.reuseHits)
+	}
+	return n + p.getOverflowHits()
+}
+
+// getOverflowHits returns the number of byte slices reused from the overflow tier
+func (p *CapacityPools) getOverflowHits() uint64 {
+	if p.overflow == nil {
+		return 0
+	}
+	return atomic.LoadUint64(&p.overflow.hits)
+}
+
+// getReleasedCount returns the number of byte slices put back into pools
+func (p *CapacityPools) getReleasedCount() (n uint64) {
+	for _, bp := range p.pools {
+		n += atomic.LoadUint64(&bp.releases)
+	}
+	return
+}
+
+// getDiscardCount returns the number of byte slices discarded by Release
+func (p *CapacityPools) getDiscardCount() uint64 {
+	return atomic.LoadUint64(&p.discardCount)
+}
+
 // getPoolReuseStats returns reuse statistics for each pool capacity
 func (p *CapacityPools) getPoolReuseStats(n int) []PoolStat {
+	return p.getPoolStats(n, SortByReuseHits)
+}
+
+// getPoolStats returns the top n pool statistics ordered by the given key.
+func (p *CapacityPools) getPoolStats(n int, by SortBy) []PoolStat {
 	if n <= 0 {
 		return nil
 	}
 
-	// collect non-zero reuse hits
-	type kv struct {
-		cap  int
-		hits uint64
-	}
-	arr := make([]kv, 0, len(p.pools))
+	arr := make([]PoolStat, 0, len(p.pools))
 	for _, bp := range p.pools {
 		if bp == nil {
 			continue
 		}
-		v := atomic.LoadUint64(&bp.reuseHits)
-		if v == 0 {
+		arr = append(arr, bp.stat())
+	}
+	return rankPoolStats(arr, n, by)
+}
+
+// rankPoolStats returns the top n of arr ordered by the given key, arr is modified.
+// Pools without reuse hits are skipped when ordering by reuse hits,
+// otherwise pools that have never been used are skipped.
+func rankPoolStats(arr []PoolStat, n int, by SortBy) []PoolStat {
+	ranked := arr[:0]
+	for _, st := range arr {
+		if by == SortByReuseHits && st.ReuseHits == 0 {
+			continue
+		}
+		if st.ReuseHits == 0 && st.Misses == 0 {
 			continue
 		}
-		arr = append(arr, kv{cap: bp.capacity, hits: v})
+		ranked = append(ranked, st)
 	}
 
-	if len(arr) == 0 {
+	if len(ranked) == 0 {
 		return nil
 	}
 
-	// partial sort: if arr is larger than n, use sort.Slice and then trim.
-	sort.Slice(arr, func(i, j int) bool { return arr[i].hits > arr[j].hits })
-	if len(arr) > n {
-		arr = arr[:n]
+	// stable, so that pools with equal keys keep ascending capacity order.
+	sort.SliceStable(ranked, func(i, j int) bool { return by.less(ranked[j], ranked[i]) })
+	if len(ranked) > n {
+		ranked = ranked[:n]
 	}
-
-	stats := make([]PoolStat, 0, len(arr))
-	for i, kv := range arr {
-		stats = append(stats, PoolStat{Rank: i + 1, Capacity: kv.cap, ReuseHits: kv.hits})
+	for i := range ranked {
+		ranked[i].Rank = i + 1
 	}
-	return stats
+	return ranked
+}
+
+// stat returns the statistics of this pool, without rank.
+func (bp *bytesPool) stat() PoolStat {
+	return bp.classCounters.stat(bp.capacity, 1)
 }
 
 func (p *CapacityPools) getMakePool(size int) *bytesPool {
@@ -318,21 +638,38 @@ func (p *CapacityPools) getMakePool(size int) *bytesPool {
 }
 
 func (p *CapacityPools) getReleasePool(size int) *bytesPool {
-	if size < p.minSize || size > p.maxSize {
+	idx := p.getReleaseIndex(size)
+	if idx < 0 {
 		return nil
 	}
+	return p.pools[idx]
+}
+
+// getReleaseIndex returns the index of the pool that byte slices of capacity size belong to, -1 if none.
+func (p *CapacityPools) getReleaseIndex(size int) int {
+	if size < p.minSize || size > p.maxSize {
+		return -1
+	}
 	if size == p.minSize {
-		return p.pools[0]
+		return 0
 	}
 	if size == p.maxSize {
-		return p.pools[p.maxIndex]
+		return p.maxIndex
 	}
 	idx := getIndex(size) - p.decIndex
-	pool := p.pools[idx]
-	if size < pool.capacity {
-		pool = p.pools[idx-1]
+	if size < p.pools[idx].capacity {
+		idx--
+	}
+	return idx
+}
+
+// outstanding returns the number of byte slices not yet released.
+// Releasing byte slices that did not come from the pool may make it undercount.
+func outstanding(gets, releases uint64) uint64 {
+	if releases > gets {
+		return 0
 	}
-	return pool
+	return gets - releases
 }
 
 func getIndex(n int) int {
@@ -340,73 +677,73 @@ func getIndex(n int) int {
 }
 
 func Clone(buf []byte) []byte {
-	return DefaultCapacityPools.Clone(buf)
+	return Default().Clone(buf)
 }
 
 func Make(capacity int) []byte {
-	return DefaultCapacityPools.Make(capacity)
+	return Default().Make(capacity)
 }
 
 func Make64(capacity uint64) []byte {
-	return DefaultCapacityPools.Make64(capacity)
+	return Default().Make64(capacity)
 }
 
 func MakeMax() []byte {
-	return DefaultCapacityPools.MakeMax()
+	return Default().MakeMax()
 }
 
 func MakeMin() []byte {
-	return DefaultCapacityPools.MakeMin()
+	return Default().MakeMin()
 }
 
 func New(size int) []byte {
-	return DefaultCapacityPools.New(size)
+	return Default().New(size)
 }
 
 func Get(size int) []byte {
-	return DefaultCapacityPools.Get(size)
+	return Default().Get(size)
 }
 
 func New64(size uint64) []byte {
-	return DefaultCapacityPools.New64(size)
+	return Default().New64(size)
 }
 
 func NewMax() []byte {
-	return DefaultCapacityPools.NewMax()
+	return Default().NewMax()
 }
 
 func NewMin() []byte {
-	return DefaultCapacityPools.NewMin()
+	return Default().NewMin()
 }
 
 func NewBytes(bs []byte) []byte {
-	return DefaultCapacityPools.NewBytes(bs)
+	return Default().NewBytes(bs)
 }
 
 func NewString(s string) []byte {
-	return DefaultCapacityPools.NewString(s)
+	return Default().NewString(s)
 }
 
 func Append(buf []byte, elems ...byte) []byte {
-	return DefaultCapacityPools.Append(buf, elems...)
+	return Default().Append(buf, elems...)
 }
 
 func AppendString(buf []byte, elems string) []byte {
-	return DefaultCapacityPools.AppendString(buf, elems)
+	return Default().AppendString(buf, elems)
 }
 
 func Release(buf []byte) bool {
-	return DefaultCapacityPools.Release(buf)
+	return Default().Release(buf)
 }
 
 func Put(buf []byte) {
-	DefaultCapacityPools.Put(buf)
+	Default().Put(buf)
 }
 
 func MinSize() int {
-	return DefaultCapacityPools.MinSize()
+	return Default().MinSize()
 }
 
 func MaxSize() int {
-	return DefaultCapacityPools.MaxSize()
+	return Default().MaxSize()
 }
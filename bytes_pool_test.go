@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math"
 	"runtime/debug"
+	"sync"
 	"testing"
 )
 
@@ -182,6 +183,7 @@ func TestCapacityPools_Boundary(t *testing.T) {
 }
 
 func TestCapacityPools_Default(t *testing.T) {
+	skipNoPool(t)
 	if DefaultCapacityPools.maxIndex+1 != getIndex(defaultMaxSize) {
 		t.Fatalf("expect count default pools is %d, but got %d",
 			getIndex(defaultMaxSize), DefaultCapacityPools.maxIndex+1)
@@ -387,3 +389,72 @@ func TestClone(t *testing.T) {
 		t.Fatalf("expect buf is x23, but got %s", string(buf))
 	}
 }
+
+func TestSetDefault(t *testing.T) {
+	orig := Default()
+	p := NewCapacityPools(8, 64)
+	if old := SetDefault(p); old != orig {
+		t.Fatal("expect the previous default pools")
+	}
+	defer SetDefault(orig)
+	if Default() != p || MaxSize() != 64 {
+		t.Fatal("expect the default pools are replaced")
+	}
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					Release(New(16))
+				}
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		SetDefault(NewCapacityPools(8, 64)).Close()
+	}
+	close(stop)
+	wg.Wait()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expect panic on nil default pools")
+		}
+	}()
+	SetDefault(nil)
+}
+
+func TestCapacityPools_Close(t *testing.T) {
+	p := NewCapacityPools(8, 64)
+	p.SetWithStats(true)
+	buf := p.New(16)
+	if !p.Release(p.New(16)) {
+		t.Fatal("expect to release the buffer successfully, but not")
+	}
+	if p.Closed() {
+		t.Fatal("expect the pools are not closed")
+	}
+
+	p.Close()
+	p.Close()
+	if !p.Closed() {
+		t.Fatal("expect the pools are closed")
+	}
+	if p.Release(buf) {
+		t.Fatal("expect to release the buffer failure, but not")
+	}
+	sum := RuntimeStatsSummary(0, p)
+	if sum.DiscardCount != 1 || sum.ReleasedCount != 2 || sum.Outstanding != 0 {
+		t.Fatalf("unexpected stats: %+v", sum)
+	}
+	if buf = p.New(16); len(buf) != 16 || cap(buf) != 16 {
+		t.Fatal("expect the closed pools still hand out byte slices")
+	}
+}
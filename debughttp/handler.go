@@ -0,0 +1,196 @@
+// Package debughttp renders the state of bytespool pools for inspection on a running process.
+//
+//	http.Handle("/debug/bytespool", debughttp.New())
+//	debughttp.New().Publish("bytespool")
+package debughttp
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/fufuok/bytespool"
+	"github.com/fufuok/bytespool/buffer"
+)
+
+const (
+	// DefaultPoolName is the name of bytespool.Default().
+	DefaultPoolName = bytespool.DefaultPoolName
+
+	// BufferPoolName is the name of the buffer package pools.
+	BufferPoolName = bytespool.BufferPoolName
+)
+
+var _ http.Handler = (*Handler)(nil)
+
+// Handler renders the statistics of a set of named CapacityPools as HTML or JSON.
+// JSON is returned for "?format=json" or when the client accepts application/json.
+type Handler struct {
+	pools *bytespool.PoolSet
+}
+
+// Config is the configuration of a pool.
+type Config struct {
+	MinSize           int  `json:"MinSize"`
+	MaxSize           int  `json:"MaxSize"`
+	Classes           int  `json:"Classes"`
+	WithStats         bool `json:"WithStats"`
+	DefaultBufferSize int  `json:"DefaultBufferSize,omitempty"`
+}
+
+// PoolInfo is the state of a named pool.
+type PoolInfo struct {
+	Name    string                   `json:"Name"`
+	Config  Config                   `json:"Config"`
+	Summary bytespool.RuntimeSummary `json:"Summary"`
+	Classes []bytespool.PoolStat     `json:"Classes"`
+}
+
+// New returns a Handler of every registered pool, including the default pools and the buffer pools,
+// followed by the added pools, see bytespool.NewPoolSet.
+func New() *Handler {
+	return &Handler{pools: bytespool.NewPoolSet(true)}
+}
+
+// NewEmpty returns a Handler without any pools.
+func NewEmpty() *Handler {
+	return &Handler{pools: bytespool.NewPoolSet(false)}
+}
+
+// Add registers p under name.
+func (h *Handler) Add(name string, p *bytespool.CapacityPools) *Handler {
+	return h.AddFunc(name, func() *bytespool.CapacityPools { return p })
+}
+
+// AddFunc registers a pool resolved at every request under name.
+// Registering an existing name replaces it.
+func (h *Handler) AddFunc(name string, fn func() *bytespool.CapacityPools) *Handler {
+	h.pools.AddFunc(name, fn)
+	return h
+}
+
+// AddStats registers a pool of another kind, e.g. a bytespool.SlicePool, under name.
+// Its class capacities are in elements.
+func (h *Handler) AddStats(name string, p bytespool.StatsPool) *Handler {
+	h.pools.AddStats(name, p)
+	return h
+}
+
+// Remove unregisters name.
+func (h *Handler) Remove(name string) {
+	h.pools.Remove(name)
+}
+
+// Pools returns the current state of every pool.
+func (h *Handler) Pools() []PoolInfo {
+	infos := []PoolInfo{}
+	h.pools.RangeStats(func(name string, p bytespool.StatsPool) bool {
+		info := PoolInfo{
+			Name: name,
+			Config: Config{
+				MinSize:   p.MinSize(),
+				MaxSize:   p.MaxSize(),
+				Classes:   classes(p),
+				WithStats: p.GetWithStats(),
+			},
+			Summary: p.StatsSummaryBy(0, bytespool.SortByReuseHits),
+			Classes: p.PoolStats(),
+		}
+		if p == bytespool.StatsPool(buffer.Pools()) {
+			info.Config.DefaultBufferSize = buffer.DefaultBufferSize
+		}
+		infos = append(infos, info)
+		return true
+	})
+	return infos
+}
+
+// classes returns the number of classes of p.
+func classes(p bytespool.StatsPool) int {
+	switch c := p.(type) {
+	case *bytespool.CapacityPools:
+		return c.Classes()
+	case interface{ Classes() []int }:
+		return len(c.Classes())
+	}
+	return 0
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	infos := h.Pools()
+	if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(infos)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := pageTemplate.Execute(w, infos); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Publish publishes the state of every registered pool as an expvar variable.
+// Like expvar.Publish, it panics if the name is already registered.
+func (h *Handler) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return h.Pools()
+	}))
+}
+
+var pageTemplate = template.Must(template.New("bytespool").Funcs(template.FuncMap{
+	"percent": func(f float64) string {
+		return fmt.Sprintf("%.1f%%", f*100)
+	},
+	"float": func(f float64) string {
+		return fmt.Sprintf("%.2f", f)
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>bytespool</title>
+<style>
+body { font-family: sans-serif; font-size: 14px; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 2px 8px; text-align: right; }
+th { background: #eee; }
+</style>
+</head>
+<body>
+<p><a href="?format=json">json</a></p>
+{{range .}}
+<h2>{{.Name}}</h2>
+<table>
+<tr><th>min size</th><th>max size</th><th>classes</th><th>stats</th>{{if .Config.DefaultBufferSize}}<th>default buffer size</th>{{end}}</tr>
+<tr><td>{{.Config.MinSize}}</td><td>{{.Config.MaxSize}}</td><td>{{.Config.Classes}}</td><td>{{.Config.WithStats}}</td>{{if .Config.DefaultBufferSize}}<td>{{.Config.DefaultBufferSize}}</td>{{end}}</tr>
+</table>
+{{with .Summary}}
+<table>
+<tr><th>new</th><th>new bytes</th><th>reused</th><th>reused bytes</th><th>out</th><th>out bytes</th><th>released</th><th>discarded</th><th>outstanding</th></tr>
+<tr><td>{{.NewCount}}</td><td>{{.NewBytes}}</td><td>{{.ReusedCount}}</td><td>{{.ReusedBytes}}</td><td>{{.OutCount}}</td><td>{{.OutBytes}}</td><td>{{.ReleasedCount}}</td><td>{{.DiscardCount}}</td><td>{{.Outstanding}}</td></tr>
+</table>
+{{if .Rates}}
+<table>
+<tr><th>window</th><th>allocs/s</th><th>new/s</th><th>out/s</th><th>reused/s</th><th>reuse</th></tr>
+{{range .Rates}}<tr><td>{{.Window}}</td><td>{{float .AllocsPerSec}}</td><td>{{float .NewPerSec}}</td><td>{{float .OutPerSec}}</td><td>{{float .ReusedPerSec}}</td><td>{{percent .ReuseRatio}}</td></tr>
+{{end}}</table>
+{{end}}
+{{end}}
+{{if .Classes}}
+<table>
+<tr><th>class</th><th>hits</th><th>misses</th><th>bytes</th><th>waste</th><th>reuse</th><th>releases</th><th>outstanding</th></tr>
+{{range .Classes}}<tr><td>{{.Capacity}}</td><td>{{.ReuseHits}}</td><td>{{.Misses}}</td><td>{{.Bytes}}</td><td>{{.Waste}}</td><td>{{percent .ReuseRatio}}</td><td>{{.Releases}}</td><td>{{.Outstanding}}</td></tr>
+{{end}}</table>
+{{else}}
+<p>statistics collection is disabled</p>
+{{end}}
+{{end}}
+</body>
+</html>
+`))
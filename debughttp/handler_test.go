@@ -0,0 +1,114 @@
+package debughttp
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fufuok/bytespool"
+	"github.com/fufuok/bytespool/buffer"
+)
+
+func TestHandler_JSON(t *testing.T) {
+	if bytespool.NoPool {
+		t.Skip("byte slices are not reused with the bytespool_nopool tag")
+	}
+	p := bytespool.NewCapacityPools(8, 64)
+	p.SetWithStats(true)
+	p.Release(p.New(20))
+	_ = p.New(20)
+
+	h := NewEmpty().Add("api", p)
+	req := httptest.NewRequest(http.MethodGet, "/debug/bytespool?format=json", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Fatalf("unexpected content type: %s", ct)
+	}
+	var infos []PoolInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &infos); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(infos) != 1 || infos[0].Name != "api" {
+		t.Fatalf("unexpected pools: %+v", infos)
+	}
+	info := infos[0]
+	if info.Config.MinSize != 8 || info.Config.MaxSize != 64 || info.Config.Classes != 4 || !info.Config.WithStats {
+		t.Fatalf("unexpected config: %+v", info.Config)
+	}
+	if info.Summary.Outstanding != 1 || info.Summary.NewCount+info.Summary.ReusedCount != 2 || len(info.Classes) != 4 {
+		t.Fatalf("unexpected summary: %+v", info)
+	}
+	if !raceEnabled && info.Summary.ReusedCount != 1 {
+		t.Fatalf("expect a reused buffer, but got %+v", info.Summary)
+	}
+}
+
+func TestHandler_HTML(t *testing.T) {
+	p := bytespool.NewCapacityPools(8, 64)
+	p.SetWithStats(true)
+	_ = p.New(20)
+
+	h := NewEmpty().Add("<api>", p).Add("off", bytespool.NewCapacityPools(8, 64))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/bytespool", nil))
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Fatalf("unexpected content type: %s", ct)
+	}
+	out := rec.Body.String()
+	for _, want := range []string{"<h2>&lt;api&gt;</h2>", "<td>32</td>", "<h2>off</h2>", "statistics collection is disabled"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestHandler_Pools(t *testing.T) {
+	h := New()
+	infos := h.Pools()
+	// the buffer pools are the default pools unless buffer.SetCapacity is called
+	if len(infos) != 1 || infos[0].Name != DefaultPoolName || infos[0].Config.DefaultBufferSize == 0 {
+		t.Fatalf("unexpected pools: %+v", infos)
+	}
+
+	// pools registered after New are discovered at every request
+	_ = bytespool.Register("handler-test", bytespool.NewCapacityPools(8, 64))
+	infos = h.Pools()
+	bytespool.Unregister("handler-test")
+	if len(infos) != 2 || infos[1].Name != "handler-test" {
+		t.Fatalf("unexpected pools: %+v", infos)
+	}
+
+	h = NewEmpty().AddFunc(BufferPoolName, buffer.Pools)
+	infos = h.Pools()
+	if len(infos) != 1 || infos[0].Name != BufferPoolName {
+		t.Fatalf("unexpected pools: %+v", infos)
+	}
+	h.Remove(BufferPoolName)
+	if len(h.Pools()) != 0 {
+		t.Fatal("expect no pools")
+	}
+}
+
+func TestHandler_Publish(t *testing.T) {
+	// the name can only be published once per process, e.g. with -count=2
+	if expvar.Get("bytespool_test") == nil {
+		NewEmpty().Add("x", bytespool.NewCapacityPools(8, 64)).Publish("bytespool_test")
+	}
+	v := expvar.Get("bytespool_test")
+	if v == nil {
+		t.Fatal("expect the expvar variable is published")
+	}
+	var infos []PoolInfo
+	if err := json.Unmarshal([]byte(v.String()), &infos); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(infos) != 1 || infos[0].Name != "x" {
+		t.Fatalf("unexpected pools: %+v", infos)
+	}
+}
@@ -19,5 +19,5 @@ func main() {
 	fmt.Println(string(js))
 
 	// Output:
-	// {"NewBytes":24,"OutBytes":0,"OutCount":0,"ReusedBytes":15984,"TopPools":[{"Rank":1,"Capacity":16,"ReuseHits":999}]}
+	// {"NewBytes":24,"NewCount":2,"OutBytes":0,"OutCount":0,"ReusedBytes":15984,"ReusedCount":999,"ReleasedCount":1000,"DiscardCount":0,"Outstanding":1,"TrimmedBytes":0,"TrimmedCount":0,"OverflowHits":0,"OverflowBytes":0,"OverflowEvict":0,"OverflowCache":0,"TopPools":[{"Rank":1,"Capacity":16,"ReuseHits":999,"Misses":1,"Bytes":16000,"Waste":6000,"ReuseRatio":0.999,"MissRatio":0.001,"Releases":1000,"Outstanding":0}],"Tags":null,"Lifetimes":null,"Rates":null}
 }
@@ -78,78 +78,172 @@ func main() {
 	m, _ = json.MarshalIndent(summary, "", "  ")
 	fmt.Println(string(m))
 
+	// Human-readable table, ordered by wasted capacity
+	summary = bytespool.RuntimeStatsSummaryBy(3, bytespool.SortByWaste, bspool)
+	fmt.Print(summary)
+
 	// Output:
 	// Runtime Stats:
-	//  NewBytes: 2040
-	//  OutBytes: 1025
-	//  OutCount: 1
-	//  ReusedBytes: 671448
+	//   NewBytes: 2040
+	//   OutBytes: 1025
+	//   OutCount: 1
+	//   ReusedBytes: 671448
 	// Pool Reuse Stats:
-	//  Rank 1: Capacity 1024, ReuseHits 486 times
-	//  Rank 2: Capacity 512, ReuseHits 255 times
-	//  Rank 3: Capacity 256, ReuseHits 127 times
-	//  Rank 4: Capacity 128, ReuseHits 63 times
-	//  Rank 5: Capacity 64, ReuseHits 31 times
+	//   Rank 1: Capacity 1024, ReuseHits 486 times
+	//   Rank 2: Capacity 512, ReuseHits 255 times
+	//   Rank 3: Capacity 256, ReuseHits 127 times
+	//   Rank 4: Capacity 128, ReuseHits 63 times
+	//   Rank 5: Capacity 64, ReuseHits 31 times
 	// {
-	//  "NewBytes": 2040,
-	//  "OutBytes": 1025,
-	//  "OutCount": 1,
-	//  "ReusedBytes": 671448,
-	//  "TopPools": [
-	//    {
-	//      "Rank": 1,
-	//      "Capacity": 1024,
-	//      "ReuseHits": 486
-	//    },
-	//    {
-	//      "Rank": 2,
-	//      "Capacity": 512,
-	//      "ReuseHits": 255
-	//    },
-	//    {
-	//      "Rank": 3,
-	//      "Capacity": 256,
-	//      "ReuseHits": 127
-	//    },
-	//    {
-	//      "Rank": 4,
-	//      "Capacity": 128,
-	//      "ReuseHits": 63
-	//    },
-	//    {
-	//      "Rank": 5,
-	//      "Capacity": 64,
-	//      "ReuseHits": 31
-	//    },
-	//    {
-	//      "Rank": 6,
-	//      "Capacity": 32,
-	//      "ReuseHits": 15
-	//    },
-	//    {
-	//      "Rank": 7,
-	//      "Capacity": 8,
-	//      "ReuseHits": 9
-	//    },
-	//    {
-	//      "Rank": 8,
-	//      "Capacity": 16,
-	//      "ReuseHits": 7
-	//    }
-	//  ]
+	//   "NewBytes": 2040,
+	//   "NewCount": 8,
+	//   "OutBytes": 1025,
+	//   "OutCount": 1,
+	//   "ReusedBytes": 671448,
+	//   "ReusedCount": 993,
+	//   "ReleasedCount": 1000,
+	//   "DiscardCount": 0,
+	//   "Outstanding": 1,
+	//   "TrimmedBytes": 0,
+	//   "TrimmedCount": 0,
+	//   "OverflowHits": 0,
+	//   "OverflowBytes": 0,
+	//   "OverflowEvict": 0,
+	//   "OverflowCache": 0,
+	//   "TopPools": [
+	//     {
+	//       "Rank": 1,
+	//       "Capacity": 1024,
+	//       "ReuseHits": 486,
+	//       "Misses": 1,
+	//       "Bytes": 498688,
+	//       "Waste": 130516,
+	//       "ReuseRatio": 0.997946611909651,
+	//       "MissRatio": 0.002053388090349076,
+	//       "Releases": 487,
+	//       "Outstanding": 0
+	//     },
+	//     {
+	//       "Rank": 2,
+	//       "Capacity": 512,
+	//       "ReuseHits": 255,
+	//       "Misses": 1,
+	//       "Bytes": 131072,
+	//       "Waste": 32640,
+	//       "ReuseRatio": 0.99609375,
+	//       "MissRatio": 0.00390625,
+	//       "Releases": 256,
+	//       "Outstanding": 0
+	//     },
+	//     {
+	//       "Rank": 3,
+	//       "Capacity": 256,
+	//       "ReuseHits": 127,
+	//       "Misses": 1,
+	//       "Bytes": 32768,
+	//       "Waste": 8128,
+	//       "ReuseRatio": 0.9921875,
+	//       "MissRatio": 0.0078125,
+	//       "Releases": 128,
+	//       "Outstanding": 0
+	//     },
+	//     {
+	//       "Rank": 4,
+	//       "Capacity": 128,
+	//       "ReuseHits": 63,
+	//       "Misses": 1,
+	//       "Bytes": 8192,
+	//       "Waste": 2016,
+	//       "ReuseRatio": 0.984375,
+	//       "MissRatio": 0.015625,
+	//       "Releases": 64,
+	//       "Outstanding": 0
+	//     },
+	//     {
+	//       "Rank": 5,
+	//       "Capacity": 64,
+	//       "ReuseHits": 31,
+	//       "Misses": 1,
+	//       "Bytes": 2048,
+	//       "Waste": 496,
+	//       "ReuseRatio": 0.96875,
+	//       "MissRatio": 0.03125,
+	//       "Releases": 32,
+	//       "Outstanding": 0
+	//     },
+	//     {
+	//       "Rank": 6,
+	//       "Capacity": 32,
+	//       "ReuseHits": 15,
+	//       "Misses": 1,
+	//       "Bytes": 512,
+	//       "Waste": 120,
+	//       "ReuseRatio": 0.9375,
+	//       "MissRatio": 0.0625,
+	//       "Releases": 16,
+	//       "Outstanding": 0
+	//     },
+	//     {
+	//       "Rank": 7,
+	//       "Capacity": 8,
+	//       "ReuseHits": 9,
+	//       "Misses": 1,
+	//       "Bytes": 80,
+	//       "Waste": 36,
+	//       "ReuseRatio": 0.9,
+	//       "MissRatio": 0.1,
+	//       "Releases": 9,
+	//       "Outstanding": 1
+	//     },
+	//     {
+	//       "Rank": 8,
+	//       "Capacity": 16,
+	//       "ReuseHits": 7,
+	//       "Misses": 1,
+	//       "Bytes": 128,
+	//       "Waste": 28,
+	//       "ReuseRatio": 0.875,
+	//       "MissRatio": 0.125,
+	//       "Releases": 8,
+	//       "Outstanding": 0
+	//     }
+	//   ],
+	//   "Tags": null,
+	//   "Lifetimes": null,
+	//   "Rates": null
 	// }
 	// Default Pool Runtime Stats:
-	//  NewBytes: 0
-	//  OutBytes: 0
-	//  OutCount: 0
-	//  ReusedBytes: 0
+	//   NewBytes: 0
+	//   OutBytes: 0
+	//   OutCount: 0
+	//   ReusedBytes: 0
 	// Default Pool Reuse Stats:
-	//  No pool reuse stats available
+	//   No pool reuse stats available
 	// {
-	//  "NewBytes": 0,
-	//  "OutBytes": 0,
-	//  "OutCount": 0,
-	//  "ReusedBytes": 0,
-	//  "TopPools": null
+	//   "NewBytes": 0,
+	//   "NewCount": 0,
+	//   "OutBytes": 0,
+	//   "OutCount": 0,
+	//   "ReusedBytes": 0,
+	//   "ReusedCount": 0,
+	//   "ReleasedCount": 0,
+	//   "DiscardCount": 0,
+	//   "Outstanding": 0,
+	//   "TrimmedBytes": 0,
+	//   "TrimmedCount": 0,
+	//   "OverflowHits": 0,
+	//   "OverflowBytes": 0,
+	//   "OverflowEvict": 0,
+	//   "OverflowCache": 0,
+	//   "TopPools": null,
+	//   "Tags": null,
+	//   "Lifetimes": null,
+	//   "Rates": null
 	// }
+	// new: 8 (2.0 KiB), reused: 993 (655.7 KiB), out: 1 (1.0 KiB)
+	// released: 1000, discarded: 0, outstanding: 1
+	//   rank  class  hits  misses    bytes      waste  reuse  outstanding
+	//      1  1 KiB   486       1  487 KiB  127.5 KiB  99.8%            0
+	//      2  512 B   255       1  128 KiB   31.9 KiB  99.6%            0
+	//      3  256 B   127       1   32 KiB    7.9 KiB  99.2%            0
 }
@@ -0,0 +1,113 @@
+package bytespool
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Join concatenates the parts with sep between them into a byte slice of the exact size.
+func (p *CapacityPools) Join(sep []byte, parts ...[]byte) []byte {
+	if len(parts) == 0 {
+		return p.Make(0)
+	}
+	size := len(sep) * (len(parts) - 1)
+	for _, b := range parts {
+		size += len(b)
+	}
+	buf := p.Make(size)
+	buf = append(buf, parts[0]...)
+	for _, b := range parts[1:] {
+		buf = append(buf, sep...)
+		buf = append(buf, b...)
+	}
+	return buf
+}
+
+// ConcatStrings concatenates the strings into a byte slice of the exact size.
+func (p *CapacityPools) ConcatStrings(parts ...string) []byte {
+	size := 0
+	for _, s := range parts {
+		size += len(s)
+	}
+	buf := p.Make(size)
+	for _, s := range parts {
+		buf = append(buf, s...)
+	}
+	return buf
+}
+
+// Sprintf formats according to a format specifier into a byte slice of the pools,
+// sized for the result: it is formatted into a reused scratch buffer first, then copied.
+func (p *CapacityPools) Sprintf(format string, a ...interface{}) []byte {
+	w := appendWriterPool.Get().(*appendWriter)
+	_, _ = fmt.Fprintf(w, format, a...)
+	buf := p.NewBytes(w.buf)
+	w.reset()
+	return buf
+}
+
+// Appendf formats according to a format specifier and appends the result to buf,
+// growing it through the pools like Append.
+func (p *CapacityPools) Appendf(buf []byte, format string, a ...interface{}) []byte {
+	w := appendWriterPool.Get().(*appendWriter)
+	w.p, w.buf = p, buf
+	_, _ = fmt.Fprintf(w, format, a...)
+	buf = w.buf
+	w.p, w.buf = nil, nil
+	w.reset()
+	return buf
+}
+
+// maxScratch bounds the scratch buffers kept by appendWriterPool.
+const maxScratch = 64 * 1024
+
+// appendWriter is an io.Writer appending to a byte slice through the pools,
+// or to its own scratch buffer if p is nil.
+type appendWriter struct {
+	p   *CapacityPools
+	buf []byte
+}
+
+var appendWriterPool = sync.Pool{
+	New: func() interface{} {
+		return new(appendWriter)
+	},
+}
+
+func (w *appendWriter) Write(b []byte) (int, error) {
+	if w.p == nil {
+		w.buf = append(w.buf, b...)
+	} else {
+		w.buf = w.p.Append(w.buf, b...)
+	}
+	return len(b), nil
+}
+
+// reset empties the scratch buffer and puts w back into appendWriterPool.
+func (w *appendWriter) reset() {
+	if cap(w.buf) > maxScratch {
+		w.buf = nil
+	}
+	w.buf = w.buf[:0]
+	appendWriterPool.Put(w)
+}
+
+// Join concatenates the parts with sep between them into a byte slice of the default pools.
+func Join(sep []byte, parts ...[]byte) []byte {
+	return Default().Join(sep, parts...)
+}
+
+// ConcatStrings concatenates the strings into a byte slice of the default pools.
+func ConcatStrings(parts ...string) []byte {
+	return Default().ConcatStrings(parts...)
+}
+
+// Sprintf formats according to a format specifier into a byte slice of the default pools.
+func Sprintf(format string, a ...interface{}) []byte {
+	return Default().Sprintf(format, a...)
+}
+
+// Appendf formats according to a format specifier and appends the result to buf through the default pools.
+func Appendf(buf []byte, format string, a ...interface{}) []byte {
+	return Default().Appendf(buf, format, a...)
+}
@@ -0,0 +1,67 @@
+package bytespool
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCapacityPools_Join(t *testing.T) {
+	p := NewCapacityPools(2, 64)
+	buf := p.Join([]byte(", "), []byte("a"), []byte("bc"), nil)
+	if string(buf) != "a, bc, " || cap(buf) != 8 {
+		t.Fatalf("expect %q, but got %q, cap %d", "a, bc, ", buf, cap(buf))
+	}
+	if buf = p.Join([]byte(",")); len(buf) != 0 || buf == nil {
+		t.Fatal("expect an empty byte slice")
+	}
+	if buf = Join(nil, []byte("x"), []byte("y")); string(buf) != "xy" {
+		t.Fatalf("expect xy, but got %q", buf)
+	}
+}
+
+func TestCapacityPools_ConcatStrings(t *testing.T) {
+	p := NewCapacityPools(2, 64)
+	buf := p.ConcatStrings("foo", "", "bar!")
+	if string(buf) != "foobar!" || cap(buf) != 8 {
+		t.Fatalf("expect foobar!, but got %q, cap %d", buf, cap(buf))
+	}
+	if buf = ConcatStrings(); len(buf) != 0 {
+		t.Fatal("expect an empty byte slice")
+	}
+}
+
+func TestCapacityPools_Sprintf(t *testing.T) {
+	p := NewCapacityPools(2, 64)
+	p.SetWithStats(true)
+	buf := p.Sprintf("%s=%d", "k", 12345)
+	if string(buf) != "k=12345" {
+		t.Fatalf("expect k=12345, but got %q", buf)
+	}
+	buf = p.Appendf(buf, " %s", "0123456789abcdefghijklmnopqrstuvwxyz")
+	if string(buf) != "k=12345 0123456789abcdefghijklmnopqrstuvwxyz" || cap(buf) != 64 {
+		t.Fatalf("unexpected result %q, cap %d", buf, cap(buf))
+	}
+	p.Release(buf)
+	if sum := RuntimeStatsSummary(0, p); sum.Outstanding != 0 {
+		t.Fatalf("expect the grown byte slices are released, but got: %+v", sum)
+	}
+	if buf = Appendf(Sprintf("%d", 1), "%d", 2); string(buf) != "12" {
+		t.Fatalf("expect 12, but got %q", buf)
+	}
+}
+
+func TestCapacityPools_SprintfSize(t *testing.T) {
+	p := NewCapacityPools(2, 1024)
+	p.SetWithStats(true)
+	// a short format with a long result is sized once for the result
+	buf := p.Sprintf("%s", strings.Repeat("x", 100))
+	if len(buf) != 100 || cap(buf) != 128 {
+		t.Fatalf("expect len 100 and cap 128, but got %d, %d", len(buf), cap(buf))
+	}
+	if sum := RuntimeStatsSummary(0, p); sum.NewCount+sum.ReusedCount != 1 || sum.ReleasedCount != 0 {
+		t.Fatalf("expect a single pool get, but got: %+v", sum)
+	}
+	if buf = p.Sprintf("%s", strings.Repeat("y", 2*maxScratch)); len(buf) != 2*maxScratch {
+		t.Fatal("unexpected result")
+	}
+}
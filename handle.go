@@ -0,0 +1,80 @@
+package bytespool
+
+import (
+	"errors"
+)
+
+// ErrForeignRelease is returned when a Handle is released into pools it was not acquired from.
+var ErrForeignRelease = errors.New("bytespool: released into a different pool")
+
+// Handle is a byte slice that remembers the pools it was acquired from,
+// so that it grows and goes back to them even when several pools are in use,
+// or the default pools were replaced meanwhile.
+// The zero value is an empty handle of the default pools at its first use.
+type Handle struct {
+	B []byte
+	p *CapacityPools
+}
+
+// NewHandle is New returning a Handle.
+func (p *CapacityPools) NewHandle(size int) Handle {
+	return Handle{B: p.New(size), p: p}
+}
+
+// MakeHandle is Make returning a Handle.
+func (p *CapacityPools) MakeHandle(capacity int) Handle {
+	return Handle{B: p.Make(capacity), p: p}
+}
+
+// ReleaseHandle releases the byte slice of h.
+// If h was acquired from other pools, the byte slice is still released to them,
+// and ErrForeignRelease is returned so that the misuse can be fixed.
+func (p *CapacityPools) ReleaseHandle(h *Handle) error {
+	origin := h.Pools()
+	h.Release()
+	if origin != p {
+		return ErrForeignRelease
+	}
+	return nil
+}
+
+// Pools returns the pools h was acquired from.
+// The default pools are resolved once, at the first use of a zero Handle.
+func (h *Handle) Pools() *CapacityPools {
+	if h.p == nil {
+		h.p = Default()
+	}
+	return h.p
+}
+
+// Bytes returns the byte slice of h.
+func (h *Handle) Bytes() []byte {
+	return h.B
+}
+
+// Append appends elems to h, growing it through its pools.
+func (h *Handle) Append(elems ...byte) {
+	h.B = h.Pools().Append(h.B, elems...)
+}
+
+// AppendString appends elems to h, growing it through its pools.
+func (h *Handle) AppendString(elems string) {
+	h.B = h.Pools().AppendString(h.B, elems)
+}
+
+// Release puts the byte slice of h back into its pools, then h is empty.
+func (h *Handle) Release() bool {
+	ok := h.Pools().Release(h.B)
+	h.B = nil
+	return ok
+}
+
+// NewHandle is New returning a Handle of the default pools.
+func NewHandle(size int) Handle {
+	return Default().NewHandle(size)
+}
+
+// MakeHandle is Make returning a Handle of the default pools.
+func MakeHandle(capacity int) Handle {
+	return Default().MakeHandle(capacity)
+}
@@ -0,0 +1,67 @@
+package bytespool
+
+import (
+	"testing"
+)
+
+func TestHandle(t *testing.T) {
+	p := NewCapacityPools(8, 64)
+	p.SetWithStats(true)
+	h := p.MakeHandle(10)
+	if len(h.B) != 0 || cap(h.B) != 16 || h.Pools() != p {
+		t.Fatalf("unexpected handle: len=%d cap=%d", len(h.B), cap(h.B))
+	}
+
+	// grows through the origin pools, not the default pools
+	h.AppendString("0123456789")
+	h.Append([]byte("abcdefghij")...)
+	if string(h.Bytes()) != "0123456789abcdefghij" || cap(h.B) != 32 {
+		t.Fatalf("unexpected handle: %q, cap=%d", h.B, cap(h.B))
+	}
+	if !h.Release() || h.B != nil {
+		t.Fatal("expect to release the handle successfully, but not")
+	}
+	if sum := RuntimeStatsSummary(0, p); sum.ReleasedCount != 2 || sum.Outstanding != 0 {
+		t.Fatalf("expect the byte slices are released to the origin pools, but got: %+v", sum)
+	}
+
+	var zero Handle
+	if zero.Pools() != Default() {
+		t.Fatal("expect the zero handle belongs to the default pools")
+	}
+
+	// the default pools of a zero handle are resolved at its first use
+	old := Default()
+	zero.AppendString("abc")
+	SetDefault(p)
+	zero.Append('d')
+	SetDefault(old)
+	if zero.Pools() != old || string(zero.B) != "abcd" {
+		t.Fatal("expect the zero handle keeps the default pools of its first use")
+	}
+	zero.Release()
+	h = NewHandle(4)
+	if len(h.B) != 4 || h.Pools() != Default() {
+		t.Fatal("unexpected handle of the default pools")
+	}
+	h.Release()
+}
+
+func TestCapacityPools_ReleaseHandle(t *testing.T) {
+	p := NewCapacityPools(8, 64)
+	q := NewCapacityPools(8, 64)
+	q.SetWithStats(true)
+
+	h := q.NewHandle(16)
+	if err := p.ReleaseHandle(&h); err != ErrForeignRelease {
+		t.Fatalf("expect ErrForeignRelease, but got %v", err)
+	}
+	if h.B != nil || RuntimeStatsSummary(0, q).ReleasedCount != 1 {
+		t.Fatal("expect the foreign handle is released to its origin pools")
+	}
+
+	h = q.NewHandle(16)
+	if err := q.ReleaseHandle(&h); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
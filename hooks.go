@@ -0,0 +1,194 @@
+package bytespool
+
+import (
+	"context"
+	"log"
+	"runtime/trace"
+	"strconv"
+)
+
+// Hooks receives the events of a CapacityPools.
+// The methods are called synchronously on the hot path,
+// they must be fast and safe for concurrent use.
+// Embed NopHooks to implement only some of them.
+type Hooks interface {
+	// OnMiss is called when a byte slice of the pool capacity is newly allocated for size.
+	OnMiss(capacity, size int)
+	// OnOutOfRange is called when size is larger than the maximum capacity and bypasses the pool.
+	OnOutOfRange(size int)
+	// OnDiscard is called when Release rejects a byte slice of the given capacity.
+	OnDiscard(capacity int, reason DiscardReason)
+	// OnReuse is called when a byte slice of the pool capacity is reused for size.
+	OnReuse(capacity, size int)
+}
+
+// DiscardReason is the reason why Release rejects a byte slice.
+type DiscardReason int
+
+const (
+	// DiscardOutOfRange is a capacity outside the range of the pools.
+	DiscardOutOfRange DiscardReason = iota
+	// DiscardClosed is a release after Close.
+	DiscardClosed
+	// DiscardPressure is a release while the memory is under pressure, see StartPressureWatch.
+	DiscardPressure
+)
+
+func (r DiscardReason) String() string {
+	switch r {
+	case DiscardOutOfRange:
+		return "out of range"
+	case DiscardClosed:
+		return "closed"
+	case DiscardPressure:
+		return "pressure"
+	}
+	return "DiscardReason(" + strconv.Itoa(int(r)) + ")"
+}
+
+// NopHooks ignores all events.
+type NopHooks struct{}
+
+func (NopHooks) OnMiss(capacity, size int)                    {}
+func (NopHooks) OnOutOfRange(size int)                        {}
+func (NopHooks) OnDiscard(capacity int, reason DiscardReason) {}
+func (NopHooks) OnReuse(capacity, size int)                   {}
+
+// SetHooks sets the event hooks of this pool, nil to remove them.
+// When unset (default), the cost is a single nil check per operation.
+// This function is not thread-safe and should be called before any pool operations.
+func (p *CapacityPools) SetHooks(h Hooks) {
+	p.hooks = h
+}
+
+// GetHooks returns the event hooks of this pool, nil if unset.
+func (p *CapacityPools) GetHooks() Hooks {
+	return p.hooks
+}
+
+// SetHooks sets the event hooks of the default pools.
+func SetHooks(h Hooks) {
+	Default().SetHooks(h)
+}
+
+// multiHooks calls each hook in order.
+type multiHooks []Hooks
+
+// MultiHooks returns Hooks that forwards every event to each of hs, nil hooks are skipped.
+func MultiHooks(hs ...Hooks) Hooks {
+	m := make(multiHooks, 0, len(hs))
+	for _, h := range hs {
+		if h != nil {
+			m = append(m, h)
+		}
+	}
+	return m
+}
+
+func (m multiHooks) OnMiss(capacity, size int) {
+	for _, h := range m {
+		h.OnMiss(capacity, size)
+	}
+}
+
+func (m multiHooks) OnOutOfRange(size int) {
+	for _, h := range m {
+		h.OnOutOfRange(size)
+	}
+}
+
+func (m multiHooks) OnDiscard(capacity int, reason DiscardReason) {
+	for _, h := range m {
+		h.OnDiscard(capacity, reason)
+	}
+}
+
+func (m multiHooks) OnReuse(capacity, size int) {
+	for _, h := range m {
+		h.OnReuse(capacity, size)
+	}
+}
+
+// LogHooks writes out-of-range allocations and out-of-range releases to a log.Logger.
+// Misses and the releases discarded because the pools are closed or under pressure,
+// which come in bursts, are only logged when Verbose is set, reuses are never logged.
+type LogHooks struct {
+	Logger  *log.Logger // the standard logger if nil
+	Verbose bool        // also log misses and all discarded releases
+	// MinOutOfRange is the smallest out-of-range size that is logged, 0 logs all.
+	MinOutOfRange int
+}
+
+func (h *LogHooks) printf(format string, v ...interface{}) {
+	if h.Logger != nil {
+		h.Logger.Printf(format, v...)
+		return
+	}
+	log.Printf(format, v...)
+}
+
+func (h *LogHooks) OnMiss(capacity, size int) {
+	if h.Verbose {
+		h.printf("bytespool: miss, capacity=%d size=%d", capacity, size)
+	}
+}
+
+func (h *LogHooks) OnOutOfRange(size int) {
+	if size >= h.MinOutOfRange {
+		h.printf("bytespool: out of range, size=%d", size)
+	}
+}
+
+func (h *LogHooks) OnDiscard(capacity int, reason DiscardReason) {
+	if reason == DiscardOutOfRange || h.Verbose {
+		h.printf("bytespool: discard, capacity=%d reason=%s", capacity, reason)
+	}
+}
+
+func (h *LogHooks) OnReuse(capacity, size int) {}
+
+// TraceHooks records pool events in the runtime/trace of a user task,
+// so that miss storms (e.g. after GC) can be seen in `go tool trace`.
+// Events are only recorded while tracing is enabled.
+type TraceHooks struct {
+	ctx  context.Context
+	task *trace.Task
+	// Reuse also records reuse events, which are usually too frequent.
+	Reuse bool
+}
+
+// NewTraceHooks starts a user task named "bytespool" in ctx.
+// Call End to end the task.
+func NewTraceHooks(ctx context.Context) *TraceHooks {
+	ctx, task := trace.NewTask(ctx, "bytespool")
+	return &TraceHooks{ctx: ctx, task: task}
+}
+
+// End ends the user task.
+func (h *TraceHooks) End() {
+	h.task.End()
+}
+
+func (h *TraceHooks) OnMiss(capacity, size int) {
+	if trace.IsEnabled() {
+		trace.Log(h.ctx, "miss", strconv.Itoa(capacity))
+	}
+}
+
+func (h *TraceHooks) OnOutOfRange(size int) {
+	if trace.IsEnabled() {
+		trace.Log(h.ctx, "outOfRange", strconv.Itoa(size))
+	}
+}
+
+func (h *TraceHooks) OnDiscard(capacity int, reason DiscardReason) {
+	if trace.IsEnabled() {
+		trace.Log(h.ctx, "discard", strconv.Itoa(capacity)+" "+reason.String())
+	}
+}
+
+func (h *TraceHooks) OnReuse(capacity, size int) {
+	if h.Reuse && trace.IsEnabled() {
+		trace.Log(h.ctx, "reuse", strconv.Itoa(capacity))
+	}
+}
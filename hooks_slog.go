@@ -0,0 +1,52 @@
+//go:build go1.21
+// +build go1.21
+
+package bytespool
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogHooks writes pool events to a slog.Logger.
+// Out-of-range allocations and out-of-range releases are logged at LevelWarn,
+// misses and the releases discarded because the pools are closed or under pressure at LevelDebug,
+// reuses are never logged.
+type SlogHooks struct {
+	Logger *slog.Logger // slog.Default() if nil
+	// MinOutOfRange is the smallest out-of-range size that is logged, 0 logs all.
+	MinOutOfRange int
+}
+
+func (h *SlogHooks) logger() *slog.Logger {
+	if h.Logger != nil {
+		return h.Logger
+	}
+	return slog.Default()
+}
+
+func (h *SlogHooks) OnMiss(capacity, size int) {
+	l := h.logger()
+	if l.Enabled(context.Background(), slog.LevelDebug) {
+		l.Debug("bytespool: miss", slog.Int("capacity", capacity), slog.Int("size", size))
+	}
+}
+
+func (h *SlogHooks) OnOutOfRange(size int) {
+	if size >= h.MinOutOfRange {
+		h.logger().Warn("bytespool: out of range", slog.Int("size", size))
+	}
+}
+
+func (h *SlogHooks) OnDiscard(capacity int, reason DiscardReason) {
+	l := h.logger()
+	if reason == DiscardOutOfRange {
+		l.Warn("bytespool: discard", slog.Int("capacity", capacity), slog.String("reason", reason.String()))
+		return
+	}
+	if l.Enabled(context.Background(), slog.LevelDebug) {
+		l.Debug("bytespool: discard", slog.Int("capacity", capacity), slog.String("reason", reason.String()))
+	}
+}
+
+func (h *SlogHooks) OnReuse(capacity, size int) {}
@@ -0,0 +1,33 @@
+//go:build go1.21
+// +build go1.21
+
+package bytespool
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogHooks(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	p := NewCapacityPools(8, 64)
+	p.SetHooks(&SlogHooks{Logger: logger})
+	_ = p.New(20)
+	_ = p.New(100)
+	p.Release(make([]byte, 4))
+	p.Close()
+	p.Release(make([]byte, 8))
+	for _, want := range []string{
+		`level=DEBUG msg="bytespool: miss" capacity=32 size=20`,
+		`level=WARN msg="bytespool: out of range" size=100`,
+		`level=WARN msg="bytespool: discard" capacity=4 reason="out of range"`,
+		`level=DEBUG msg="bytespool: discard" capacity=8 reason=closed`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("missing %q in:\n%s", want, out.String())
+		}
+	}
+}
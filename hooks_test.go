@@ -0,0 +1,108 @@
+package bytespool
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"runtime/debug"
+	"runtime/trace"
+	"strings"
+	"testing"
+)
+
+type countHooks struct {
+	miss, out, discard, reuse int
+	last                      int
+	reason                    DiscardReason
+}
+
+func (h *countHooks) OnMiss(capacity, size int)  { h.miss++; h.last = capacity }
+func (h *countHooks) OnOutOfRange(size int)      { h.out++; h.last = size }
+func (h *countHooks) OnReuse(capacity, size int) { h.reuse++; h.last = capacity }
+func (h *countHooks) OnDiscard(capacity int, reason DiscardReason) {
+	h.discard++
+	h.last = capacity
+	h.reason = reason
+}
+
+func TestHooks(t *testing.T) {
+	skipNoPool(t)
+	gc := debug.SetGCPercent(-1)
+	defer debug.SetGCPercent(gc)
+
+	p := NewCapacityPools(8, 64)
+	if p.GetHooks() != nil {
+		t.Fatal("expect no hooks by default")
+	}
+	h := &countHooks{}
+	p.SetHooks(MultiHooks(nil, h, NopHooks{}))
+
+	buf := p.New(20)
+	if h.miss != 1 || h.last != 32 {
+		t.Fatalf("expect a miss of class 32, but got %+v", h)
+	}
+	p.Release(buf)
+	_ = p.New(20)
+	if h.reuse+h.miss != 2 || h.last != 32 || !raceEnabled && h.reuse != 1 {
+		t.Fatalf("expect a reuse of class 32, but got %+v", h)
+	}
+	_ = p.New(100)
+	if h.out != 1 || h.last != 100 {
+		t.Fatalf("expect an out of range of 100, but got %+v", h)
+	}
+	p.Release(make([]byte, 4))
+	if h.discard != 1 || h.last != 4 || h.reason != DiscardOutOfRange {
+		t.Fatalf("expect a discard of 4, but got %+v", h)
+	}
+	p.Release(nil)
+	if h.discard != 1 {
+		t.Fatal("expect no discard of a nil byte slice")
+	}
+	p.Close()
+	p.Release(make([]byte, 8))
+	if h.discard != 2 || h.reason != DiscardClosed || h.reason.String() != "closed" {
+		t.Fatalf("expect a discard of the closed pools, but got %+v", h)
+	}
+
+	p.SetHooks(nil)
+	_ = p.New(100)
+	if h.out != 1 {
+		t.Fatal("expect no events after the hooks are removed")
+	}
+}
+
+func TestLogHooks(t *testing.T) {
+	var out bytes.Buffer
+	p := NewCapacityPools(8, 64)
+	p.SetHooks(&LogHooks{Logger: log.New(&out, "", 0), MinOutOfRange: 100})
+	_ = p.New(20)
+	_ = p.New(99)
+	_ = p.New(100)
+	p.Release(make([]byte, 4))
+	p.Close()
+	p.Release(make([]byte, 8))
+	want := "bytespool: out of range, size=100\nbytespool: discard, capacity=4 reason=out of range\n"
+	if out.String() != want {
+		t.Fatalf("expect %q, but got %q", want, out.String())
+	}
+}
+
+func TestTraceHooks(t *testing.T) {
+	var out bytes.Buffer
+	if err := trace.Start(&out); err != nil {
+		t.Skipf("tracing is unavailable: %v", err)
+	}
+	h := NewTraceHooks(context.Background())
+	h.Reuse = true
+	p := NewCapacityPools(8, 64)
+	p.SetHooks(h)
+	p.Release(p.New(20))
+	_ = p.New(20)
+	_ = p.New(100)
+	p.Release(make([]byte, 4))
+	h.End()
+	trace.Stop()
+	if !strings.Contains(out.String(), "bytespool") || !strings.Contains(out.String(), "outOfRange") {
+		t.Fatal("expect the events in the trace")
+	}
+}
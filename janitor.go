@@ -0,0 +1,166 @@
+package bytespool
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultJanitorTTL is the default idle duration after which the janitor trims a class.
+	DefaultJanitorTTL = time.Minute
+
+	minJanitorInterval = 10 * time.Millisecond
+)
+
+// JanitorConfig configures the idle trimming janitor of a CapacityPools.
+type JanitorConfig struct {
+	// TTL is the idle duration after which the cached byte slices of a class are freed,
+	// DefaultJanitorTTL if 0. A class is used when a byte slice is acquired from or released into it.
+	// The byte slices of the overflow tier are freed TTL after their release.
+	TTL time.Duration
+	// Interval is the period of the checks, TTL/2 if 0.
+	Interval time.Duration
+	// MaxBytes is the maximum number of bytes freed per check, 0 for no limit.
+	// The largest classes fitting in the limit are trimmed first.
+	MaxBytes int
+}
+
+// janitor periodically trims the idle classes of a CapacityPools.
+type janitor struct {
+	p    *CapacityPools
+	cfg  JanitorConfig
+	stop chan struct{}
+	done chan struct{}
+}
+
+func (j *janitor) run() {
+	defer close(j.done)
+	t := time.NewTicker(j.cfg.Interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-j.stop:
+			return
+		case <-t.C:
+			j.p.Trim(j.cfg.TTL, j.cfg.MaxBytes)
+		}
+	}
+}
+
+func (j *janitor) close() {
+	close(j.stop)
+	<-j.done
+}
+
+// StartJanitor starts a background goroutine freeing the cached byte slices of the classes
+// idle for longer than cfg.TTL, largest classes first, until StopJanitor is called.
+// Starting it again replaces the running janitor.
+func (p *CapacityPools) StartJanitor(cfg JanitorConfig) {
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultJanitorTTL
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = cfg.TTL / 2
+	}
+	if cfg.Interval < minJanitorInterval {
+		cfg.Interval = minJanitorInterval
+	}
+	j := &janitor{
+		p:    p,
+		cfg:  cfg,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	p.mu.Lock()
+	old := p.janitor
+	p.janitor = j
+	if old == nil {
+		// idle durations are measured from now
+		now := sinceEpoch()
+		for _, bp := range p.pools {
+			atomic.StoreInt64(&bp.lastUsed, now)
+		}
+		atomic.StoreUint32(&p.trackIdle, 1)
+	}
+	p.mu.Unlock()
+
+	if old != nil {
+		old.close()
+	}
+	go j.run()
+}
+
+// StopJanitor stops the background janitor, if any.
+func (p *CapacityPools) StopJanitor() {
+	p.mu.Lock()
+	j := p.janitor
+	p.janitor = nil
+	atomic.StoreUint32(&p.trackIdle, 0)
+	p.mu.Unlock()
+
+	if j != nil {
+		j.close()
+	}
+}
+
+// Trim frees the cached byte slices of the classes idle for longer than ttl,
+// largest classes first, up to maxBytes (0 for no limit): the byte slices larger
+// than the rest of the limit are kept. The byte slices of the overflow tier released
+// for longer than ttl are freed first.
+// It returns the number of bytes freed.
+// The idle durations are only tracked while the janitor is running, otherwise every class is idle.
+// With a garbage collected backend, the classes are cached in sync.Pool: the byte slices held
+// privately by other processors cannot be reached and are left to the garbage collector.
+func (p *CapacityPools) Trim(ttl time.Duration, maxBytes int) int {
+	tracked := atomic.LoadUint32(&p.trackIdle) != 0
+	now := sinceEpoch()
+	trimmed := 0
+	if p.overflow != nil {
+		for _, buf := range p.overflow.expire(now, ttl, maxBytes) {
+			trimmed += cap(buf)
+			atomic.AddUint64(&p.trimmedCount, 1)
+			atomic.AddUint64(&p.trimmedBytes, uint64(cap(buf)))
+			if p.backend != nil {
+				p.backend.Free(buf[:cap(buf)])
+			}
+		}
+	}
+	for i := len(p.pools) - 1; i >= 0; i-- {
+		bp := p.pools[i]
+		if tracked && now-atomic.LoadInt64(&bp.lastUsed) < int64(ttl) {
+			continue
+		}
+		for maxBytes <= 0 || trimmed+bp.capacity <= maxBytes {
+			if !p.drainOne(bp) {
+				break
+			}
+			trimmed += bp.capacity
+			atomic.AddUint64(&p.trimmedCount, 1)
+			atomic.AddUint64(&p.trimmedBytes, uint64(bp.capacity))
+		}
+	}
+	return trimmed
+}
+
+// touch records the use of the class while the janitor is running.
+func (p *CapacityPools) touch(bp *bytesPool) {
+	if atomic.LoadUint32(&p.trackIdle) != 0 {
+		atomic.StoreInt64(&bp.lastUsed, sinceEpoch())
+	}
+}
+
+// sinceEpoch returns the monotonic time in nanoseconds.
+func sinceEpoch() int64 {
+	return int64(time.Since(epoch))
+}
+
+// StartJanitor starts the idle trimming janitor on the default pools.
+func StartJanitor(cfg JanitorConfig) {
+	Default().StartJanitor(cfg)
+}
+
+// StopJanitor stops the idle trimming janitor on the default pools.
+func StopJanitor() {
+	Default().StopJanitor()
+}
@@ -0,0 +1,101 @@
+package bytespool
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCapacityPools_Trim(t *testing.T) {
+	skipNoPool(t)
+	b := &countingBackend{}
+	p := NewCapacityPools(8, 64)
+	p.SetWithStats(true)
+	p.SetBackend(b)
+	for _, size := range []int{16, 32, 64} {
+		p.Release(p.New(size))
+	}
+
+	// never more than maxBytes
+	if n := p.Trim(0, 1); n != 0 || b.frees != 0 {
+		t.Fatalf("expect nothing is trimmed, but got %d bytes, %d frees", n, b.frees)
+	}
+	// largest classes fitting in maxBytes first
+	if n := p.Trim(0, 50); n != 48 || b.frees != 2 {
+		t.Fatalf("expect the classes 32 and 16 are trimmed, but got %d bytes, %d frees", n, b.frees)
+	}
+	if n := p.Trim(0, 0); n != 64 || b.frees != 3 {
+		t.Fatalf("expect 64 bytes are trimmed, but got %d bytes, %d frees", n, b.frees)
+	}
+	if n := p.Trim(0, 0); n != 0 {
+		t.Fatalf("expect nothing to trim, but got %d bytes", n)
+	}
+	sum := RuntimeStatsSummary(0, p)
+	if sum.TrimmedBytes != 112 || sum.TrimmedCount != 3 {
+		t.Fatalf("unexpected trimmed stats: %+v", sum)
+	}
+}
+
+func TestCapacityPools_TrimOverflow(t *testing.T) {
+	skipNoPool(t)
+	b := &countingBackend{}
+	p := NewCapacityPools(8, 64)
+	p.SetWithStats(true)
+	p.SetBackend(b)
+	p.SetOverflow(&OverflowConfig{})
+	p.Release(p.New(100))
+	p.Release(p.New(200))
+
+	// recently released
+	if n := p.Trim(time.Hour, 0); n != 0 {
+		t.Fatalf("expect the overflow tier is not trimmed, but got %d bytes", n)
+	}
+	p.Release(p.New(16))
+	// the largest byte slices fitting in maxBytes first, before the classes
+	if n := p.Trim(0, 150); n != 116 || b.frees != 2 {
+		t.Fatalf("expect 100 and 16 bytes are trimmed, but got %d bytes, %d frees", n, b.frees)
+	}
+	if n := p.Trim(0, 0); n != 200 || b.frees != 3 {
+		t.Fatalf("expect 200 bytes are trimmed, but got %d bytes, %d frees", n, b.frees)
+	}
+	sum := RuntimeStatsSummary(0, p)
+	if sum.TrimmedBytes != 316 || sum.TrimmedCount != 3 || sum.OverflowCache != 0 {
+		t.Fatalf("unexpected trimmed stats: %+v", sum)
+	}
+}
+
+func TestCapacityPools_StartJanitor(t *testing.T) {
+	skipNoPool(t)
+	p := NewCapacityPools(8, 64)
+	p.SetBackend(&countingBackend{})
+	p.StartJanitor(JanitorConfig{TTL: time.Hour})
+	p.Release(p.New(16))
+	// recently used
+	if n := p.Trim(time.Hour, 0); n != 0 {
+		t.Fatalf("expect the used class is not trimmed, but got %d bytes", n)
+	}
+
+	p.StartJanitor(JanitorConfig{TTL: 20 * time.Millisecond, Interval: 10 * time.Millisecond})
+	defer p.StopJanitor()
+	p.Release(p.New(32))
+	deadline := time.Now().Add(2 * time.Second)
+	for atomic.LoadUint64(&p.trimmedCount) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("expect the idle classes are trimmed by the janitor")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	p.StopJanitor()
+	p.StopJanitor()
+}
+
+func TestStartJanitor(t *testing.T) {
+	StartJanitor(JanitorConfig{})
+	if Default().janitor == nil || Default().janitor.cfg.Interval != DefaultJanitorTTL/2 {
+		t.Fatal("expect the janitor of the default pools is running")
+	}
+	StopJanitor()
+	if Default().janitor != nil {
+		t.Fatal("expect the janitor is stopped")
+	}
+}
@@ -0,0 +1,142 @@
+package bytespool
+
+import (
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+// epoch is the reference of the monotonic lifetime stamps.
+var epoch = time.Now()
+
+// LifetimeStat is the hold duration distribution of a pool capacity.
+// Quantiles are the upper bounds of power-of-two nanosecond buckets, capped at Max.
+type LifetimeStat struct {
+	Capacity int           `json:"Capacity"`
+	Count    uint64        `json:"Count"` // number of observed releases
+	P50      time.Duration `json:"P50"`
+	P99      time.Duration `json:"P99"`
+	Max      time.Duration `json:"Max"`
+}
+
+// lifetimeHist is a lock-free histogram of hold durations.
+type lifetimeHist struct {
+	buckets [65]uint64 // bucket i counts durations in [2^(i-1), 2^i) ns
+	count   uint64
+	max     int64
+}
+
+func (h *lifetimeHist) observe(d int64) {
+	if d < 0 {
+		d = 0
+	}
+	atomic.AddUint64(&h.buckets[bits.Len64(uint64(d))], 1)
+	atomic.AddUint64(&h.count, 1)
+	for {
+		m := atomic.LoadInt64(&h.max)
+		if d <= m || atomic.CompareAndSwapInt64(&h.max, m, d) {
+			return
+		}
+	}
+}
+
+// quantile returns the upper bound of the bucket containing the q-th quantile.
+func (h *lifetimeHist) quantile(q float64, count uint64, max int64) time.Duration {
+	if count == 0 {
+		return 0
+	}
+	rank := uint64(q*float64(count) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	var n uint64
+	for i := range h.buckets {
+		n += atomic.LoadUint64(&h.buckets[i])
+		if n >= rank {
+			var upper int64
+			if i > 0 {
+				upper = int64(1)<<uint(i) - 1
+			}
+			if upper > max || i >= 63 {
+				upper = max
+			}
+			return time.Duration(upper)
+		}
+	}
+	return time.Duration(max)
+}
+
+func (h *lifetimeHist) stat(capacity int) LifetimeStat {
+	count := atomic.LoadUint64(&h.count)
+	max := atomic.LoadInt64(&h.max)
+	return LifetimeStat{
+		Capacity: capacity,
+		Count:    count,
+		P50:      h.quantile(0.5, count, max),
+		P99:      h.quantile(0.99, count, max),
+		Max:      time.Duration(max),
+	}
+}
+
+// SetWithLifetime enables or disables recording how long byte slices are held, per capacity.
+// Only holders that stamp the acquisition time are recorded, such as buffer.Buffer.
+// This function is not thread-safe and should be called before any pool operations.
+func (p *CapacityPools) SetWithLifetime(t bool) {
+	if t && p.lifetimes == nil {
+		p.lifetimes = make([]lifetimeHist, len(p.pools))
+	}
+	p.withLifetime = t
+}
+
+// GetWithLifetime returns whether hold durations are recorded.
+func (p *CapacityPools) GetWithLifetime() bool {
+	return p.withLifetime
+}
+
+// LifetimeStamp returns the monotonic acquisition stamp to pass to ObserveLifetime,
+// or 0 if lifetime recording is disabled.
+func (p *CapacityPools) LifetimeStamp() int64 {
+	if !p.withLifetime {
+		return 0
+	}
+	// never 0 when enabled
+	return int64(time.Since(epoch)) | 1
+}
+
+// ObserveLifetime records the hold duration of a byte slice of the given capacity,
+// acquired at stamp (from LifetimeStamp). Zero stamps and out-of-range capacities are ignored.
+func (p *CapacityPools) ObserveLifetime(capacity int, stamp int64) {
+	if stamp == 0 || !p.withLifetime {
+		return
+	}
+	idx := p.getReleaseIndex(capacity)
+	if idx < 0 {
+		return
+	}
+	p.lifetimes[idx].observe(int64(time.Since(epoch)) - stamp)
+}
+
+// getLifetimeStats returns the hold duration distribution of every observed capacity.
+func (p *CapacityPools) getLifetimeStats() []LifetimeStat {
+	if p.lifetimes == nil {
+		return nil
+	}
+	var stats []LifetimeStat
+	for i := range p.lifetimes {
+		st := p.lifetimes[i].stat(p.pools[i].capacity)
+		if st.Count > 0 {
+			stats = append(stats, st)
+		}
+	}
+	return stats
+}
+
+// LifetimeStats returns the hold duration distribution of every observed capacity of the provided
+// CapacityPools (or the default pools when none provided), in ascending capacity order.
+func LifetimeStats(ps ...*CapacityPools) []LifetimeStat {
+	p := Default()
+	if len(ps) > 0 {
+		p = ps[0]
+	}
+	return p.getLifetimeStats()
+}
@@ -0,0 +1,64 @@
+package bytespool
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLifetimeHist(t *testing.T) {
+	var h lifetimeHist
+	if st := h.stat(8); st.Count != 0 || st.P50 != 0 || st.Max != 0 {
+		t.Fatalf("unexpected empty stat: %+v", st)
+	}
+	for i := 0; i < 98; i++ {
+		h.observe(int64(100 * time.Microsecond))
+	}
+	h.observe(int64(time.Millisecond))
+	h.observe(int64(time.Second))
+	h.observe(-1)
+
+	st := h.stat(8)
+	if st.Count != 101 || st.Max != time.Second {
+		t.Fatalf("unexpected stat: %+v", st)
+	}
+	// 100µs is in the bucket [2^16, 2^17) ns
+	if st.P50 < 100*time.Microsecond || st.P50 >= 2*100*time.Microsecond {
+		t.Fatalf("unexpected p50: %s", st.P50)
+	}
+	if st.P99 < time.Millisecond || st.P99 >= 2*time.Millisecond {
+		t.Fatalf("unexpected p99: %s", st.P99)
+	}
+	if q := h.quantile(1, st.Count, int64(st.Max)); q != time.Second {
+		t.Fatalf("expect p100 is capped at max, but got %s", q)
+	}
+}
+
+func TestObserveLifetime(t *testing.T) {
+	p := NewCapacityPools(8, 64)
+	if p.LifetimeStamp() != 0 || p.GetWithLifetime() {
+		t.Fatal("expect lifetime recording is disabled by default")
+	}
+	p.ObserveLifetime(8, 1)
+	if LifetimeStats(p) != nil {
+		t.Fatal("expect no lifetime statistics when disabled")
+	}
+
+	p.SetWithLifetime(true)
+	p.SetWithStats(true)
+	stamp := p.LifetimeStamp()
+	if stamp == 0 {
+		t.Fatal("expect a non-zero stamp")
+	}
+	time.Sleep(time.Millisecond)
+	p.ObserveLifetime(20, stamp)
+	p.ObserveLifetime(100, stamp)
+	p.ObserveLifetime(8, 0)
+
+	stats := LifetimeStats(p)
+	if len(stats) != 1 || stats[0].Capacity != 16 || stats[0].Count != 1 || stats[0].Max < time.Millisecond {
+		t.Fatalf("unexpected lifetime stats: %+v", stats)
+	}
+	if summary := RuntimeStatsSummary(0, p); len(summary.Lifetimes) != 1 {
+		t.Fatalf("expect lifetimes in the summary, but got %+v", summary.Lifetimes)
+	}
+}
@@ -0,0 +1,47 @@
+package bytespool
+
+import (
+	"testing"
+)
+
+// skipNoPool skips the tests relying on the reuse of byte slices.
+func skipNoPool(t testing.TB) {
+	if NoPool {
+		t.Skip("byte slices are not reused with the bytespool_nopool tag")
+	}
+}
+
+func TestNoPool(t *testing.T) {
+	b := &countingBackend{}
+	p := NewCapacityPools(8, 64)
+	p.SetWithStats(true)
+	p.SetBackend(b)
+
+	buf := p.New(10)
+	buf[0] = 'x'
+	if !p.Release(buf) {
+		t.Fatal("expect to release the buffer successfully, but not")
+	}
+	buf2 := p.New(10)
+	if len(buf2) != 10 || cap(buf2) != 16 {
+		t.Fatalf("expect the class capacity, but got len=%d, cap=%d", len(buf2), cap(buf2))
+	}
+	sum := RuntimeStatsSummary(0, p)
+
+	if !NoPool {
+		if &buf[0] != &buf2[0] || sum.ReusedCount != 1 || b.allocs != 1 {
+			t.Fatal("expect the byte slice is reused")
+		}
+		return
+	}
+	if &buf[0] == &buf2[0] || buf2[0] != 0 {
+		t.Fatal("expect a new zeroed byte slice")
+	}
+	if sum.NewCount != 2 || sum.ReusedCount != 0 || sum.ReleasedCount != 1 || b.allocs != 0 {
+		t.Fatalf("unexpected stats: %+v, backend allocs: %d", sum, b.allocs)
+	}
+	p.Release(p.New(100))
+	if b.frees != 0 {
+		t.Fatal("expect the backend is not used")
+	}
+}
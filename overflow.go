@@ -0,0 +1,212 @@
+package bytespool
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultOverflowMaxBytes is the default total capacity retained by the overflow tier.
+const DefaultOverflowMaxBytes = 256 * 1024 * 1024 // 256 MiB
+
+// OverflowConfig configures the overflow tier of a CapacityPools,
+// which caches the byte slices larger than the maximum capacity.
+type OverflowConfig struct {
+	// MaxBytes is the total capacity of the cached byte slices, DefaultOverflowMaxBytes if 0.
+	// The least recently released byte slices are evicted beyond it.
+	MaxBytes int
+	// MaxWaste is the largest fraction of unrequested capacity accepted on reuse, 1 (twice the size) if 0,
+	// like the power-of-two classes.
+	MaxWaste float64
+}
+
+// overflow is a small cache of large byte slices ordered by capacity, reused by best fit.
+type overflow struct {
+	mu       sync.Mutex
+	entries  []overflowEntry // Ordered by capacity
+	bytes    int             // Total capacity of the entries
+	maxBytes int
+	maxWaste float64
+	seq      uint64 // Release sequence for the LRU eviction
+
+	hits      uint64 // Number of byte slices reused from the cache
+	hitBytes  uint64 // Capacity bytes reused from the cache
+	evictions uint64 // Number of byte slices evicted to stay within maxBytes
+}
+
+type overflowEntry struct {
+	buf      []byte
+	used     uint64
+	released int64 // sinceEpoch at the release, for Trim
+}
+
+// SetOverflow enables the overflow tier for the sizes larger than the maximum capacity,
+// which are otherwise allocated on every New and discarded on Release.
+// Pass nil to disable it, the cached byte slices are dropped.
+// This function is not thread-safe and should be called before any pool operations.
+func (p *CapacityPools) SetOverflow(cfg *OverflowConfig) {
+	if p.overflow != nil {
+		p.drainOverflow()
+	}
+	if cfg == nil {
+		p.overflow = nil
+		return
+	}
+	o := &overflow{maxBytes: cfg.MaxBytes, maxWaste: cfg.MaxWaste}
+	if o.maxBytes <= 0 {
+		o.maxBytes = DefaultOverflowMaxBytes
+	}
+	if o.maxWaste <= 0 {
+		o.maxWaste = 1
+	}
+	p.overflow = o
+}
+
+// SetOverflow enables the overflow tier of the default pools, nil to disable it.
+func SetOverflow(cfg *OverflowConfig) {
+	Default().SetOverflow(cfg)
+}
+
+// get returns the smallest cached byte slice fitting size, nil if none.
+func (o *overflow) get(size int) []byte {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	i := sort.Search(len(o.entries), func(i int) bool {
+		return cap(o.entries[i].buf) >= size
+	})
+	if i == len(o.entries) || float64(cap(o.entries[i].buf)-size) > o.maxWaste*float64(size) {
+		return nil
+	}
+	buf := o.entries[i].buf
+	o.remove(i)
+	return buf
+}
+
+// put caches buf and returns the byte slices evicted to stay within maxBytes.
+// It reports false if buf is larger than maxBytes.
+func (o *overflow) put(buf []byte) (evicted [][]byte, ok bool) {
+	c := cap(buf)
+	if c > o.maxBytes {
+		return nil, false
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for o.bytes+c > o.maxBytes {
+		lru := 0
+		for i := range o.entries {
+			if o.entries[i].used < o.entries[lru].used {
+				lru = i
+			}
+		}
+		evicted = append(evicted, o.entries[lru].buf)
+		o.remove(lru)
+	}
+	o.seq++
+	i := sort.Search(len(o.entries), func(i int) bool {
+		return cap(o.entries[i].buf) >= c
+	})
+	o.entries = append(o.entries, overflowEntry{})
+	copy(o.entries[i+1:], o.entries[i:])
+	o.entries[i] = overflowEntry{buf: buf[:0], used: o.seq, released: sinceEpoch()}
+	o.bytes += c
+	return evicted, true
+}
+
+// expire removes and returns the byte slices released for longer than ttl, largest first,
+// up to maxBytes (0 for no limit).
+func (o *overflow) expire(now int64, ttl time.Duration, maxBytes int) (expired [][]byte) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	freed := 0
+	for i := len(o.entries) - 1; i >= 0; i-- {
+		e := o.entries[i]
+		if now-e.released < int64(ttl) || maxBytes > 0 && freed+cap(e.buf) > maxBytes {
+			continue
+		}
+		freed += cap(e.buf)
+		expired = append(expired, e.buf)
+		o.remove(i)
+	}
+	return expired
+}
+
+// remove deletes the entry i, o.mu must be held.
+func (o *overflow) remove(i int) {
+	o.bytes -= cap(o.entries[i].buf)
+	copy(o.entries[i:], o.entries[i+1:])
+	o.entries[len(o.entries)-1] = overflowEntry{}
+	o.entries = o.entries[:len(o.entries)-1]
+}
+
+// newOverflow returns a byte slice of size from the overflow tier, nil on miss.
+func (p *CapacityPools) newOverflow(size int) []byte {
+	if NoPool {
+		return nil
+	}
+	buf := p.overflow.get(size)
+	if buf == nil {
+		return nil
+	}
+	if p.withStats {
+		// also counted as reused, but not as outstanding, see RuntimeSummary.Outstanding
+		atomic.AddUint64(&p.overflow.hits, 1)
+		atomic.AddUint64(&p.overflow.hitBytes, uint64(cap(buf)))
+		atomic.AddUint64(&p.reusedBytes, uint64(cap(buf)))
+	}
+	if p.hooks != nil {
+		p.hooks.OnReuse(cap(buf), size)
+	}
+	return buf[:size]
+}
+
+// releaseOverflow caches a byte slice larger than the maximum capacity.
+func (p *CapacityPools) releaseOverflow(buf []byte) bool {
+	if flags := atomic.LoadUint32(&p.flags); flags != 0 {
+		p.discard(buf, flagReason(flags))
+		return false
+	}
+	if NoPool {
+		p.discard(buf, DiscardOutOfRange)
+		return false
+	}
+	evicted, ok := p.overflow.put(buf)
+	if !ok {
+		p.discard(buf, DiscardOutOfRange)
+		return false
+	}
+	// evictions are not rejected releases: not counted as discarded and not reported to OnDiscard
+	for _, b := range evicted {
+		if p.withStats {
+			atomic.AddUint64(&p.overflow.evictions, 1)
+		}
+		if p.backend != nil {
+			p.backend.Free(b[:cap(b)])
+		}
+	}
+	return true
+}
+
+// drainOverflow drops the cached byte slices of the overflow tier and returns their number and bytes.
+func (p *CapacityPools) drainOverflow() (n, bytes int) {
+	o := p.overflow
+	o.mu.Lock()
+	entries := o.entries
+	o.entries, o.bytes = nil, 0
+	o.mu.Unlock()
+	for _, e := range entries {
+		n++
+		bytes += cap(e.buf)
+		if p.backend != nil {
+			p.backend.Free(e.buf[:cap(e.buf)])
+		}
+	}
+	return n, bytes
+}
+
+// cachedBytes returns the capacity bytes currently cached.
+func (o *overflow) cachedBytes() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.bytes
+}
@@ -0,0 +1,77 @@
+package bytespool
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCapacityPools_Overflow(t *testing.T) {
+	skipNoPool(t)
+	p := NewCapacityPools(8, 64)
+	p.SetWithStats(true)
+	p.SetOverflow(&OverflowConfig{MaxBytes: 1000})
+	b0 := &countingBackend{}
+	p.SetBackend(b0)
+	h := &countHooks{}
+	p.SetHooks(h)
+
+	a := p.New(100)
+	b := p.New(300)
+	if !p.Release(a) || !p.Release(b) {
+		t.Fatal("expect the large byte slices are cached")
+	}
+
+	// best fit
+	buf := p.New(90)
+	if len(buf) != 90 || cap(buf) != 100 || &buf[0] != &a[0] {
+		t.Fatalf("expect the smallest fitting byte slice is reused, but got cap %d", cap(buf))
+	}
+	// too wasteful
+	if buf = p.New(120); cap(buf) != 120 {
+		t.Fatalf("expect a new byte slice, but got cap %d", cap(buf))
+	}
+	sum := RuntimeStatsSummary(0, p)
+	if sum.OverflowHits != 1 || sum.OverflowBytes != 100 || sum.OverflowCache != 300 || sum.OutCount != 3 ||
+		sum.ReusedCount != 1 || sum.ReusedBytes != 100 || sum.Outstanding != 0 {
+		t.Fatalf("unexpected overflow stats: %+v", sum)
+	}
+	if !strings.Contains(sum.String(), "overflow hits: 1") {
+		t.Fatalf("expect the overflow stats are printed, but got:\n%s", sum.String())
+	}
+
+	// least recently released first
+	p.Release(p.New(400))
+	p.Release(p.New(500))
+	sum = RuntimeStatsSummary(0, p)
+	if sum.OverflowEvict != 1 || sum.OverflowCache != 900 {
+		t.Fatalf("expect the oldest byte slice is evicted, but got: %+v", sum)
+	}
+	// evictions are not rejected releases
+	if sum.DiscardCount != 0 || h.discard != 0 || b0.frees != 1 {
+		t.Fatalf("expect the evicted byte slice is freed without discard, but got: %+v, %+v", sum, h)
+	}
+	if buf = p.New(300); cap(buf) != 400 || &buf[0] == &b[0] {
+		t.Fatalf("expect the evicted byte slice is not reused, but got cap %d", cap(buf))
+	}
+	// larger than the cache
+	if p.Release(p.New(2000)) || h.discard != 1 {
+		t.Fatal("expect the byte slice larger than MaxBytes is discarded")
+	}
+
+	if n := p.Drain(); n != 500 {
+		t.Fatalf("expect the overflow tier is drained, but got %d bytes", n)
+	}
+	p.SetOverflow(nil)
+	if p.Release(p.New(100)) {
+		t.Fatal("expect the large byte slice is discarded without the overflow tier")
+	}
+}
+
+func TestCapacityPools_OverflowClosed(t *testing.T) {
+	p := NewCapacityPools(8, 64)
+	p.SetOverflow(&OverflowConfig{})
+	p.Close()
+	if p.Release(p.New(100)) {
+		t.Fatal("expect the release is discarded by the closed pools")
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !bytespool_nopool
+// +build !bytespool_nopool
+
+package bytespool
+
+// NoPool reports whether the package is built with the bytespool_nopool tag:
+// every byte slice is then newly allocated zeroed memory from the heap and Release retains nothing,
+// while the API and the statistics stay the same (every get is a miss).
+// It helps to prove or rule out pool misuse when data is corrupted, e.g.:
+//
+//	go test -tags bytespool_nopool ./...
+const NoPool = false
@@ -0,0 +1,129 @@
+package bytespool
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultPressureFraction is the default fraction of the memory limit above which the pools stop retaining.
+	DefaultPressureFraction = 0.9
+
+	// DefaultPressureInterval is the default period of the memory checks.
+	DefaultPressureInterval = 100 * time.Millisecond
+)
+
+// PressureConfig configures the memory pressure watch of a CapacityPools.
+type PressureConfig struct {
+	// Fraction of the Go memory limit (debug.SetMemoryLimit) above which the released byte slices
+	// are not retained, DefaultPressureFraction if 0.
+	Fraction float64
+	// Interval is the period of the memory checks, DefaultPressureInterval if 0.
+	Interval time.Duration
+}
+
+// pressureWatch periodically compares the memory in use with the memory limit.
+type pressureWatch struct {
+	p       *CapacityPools
+	cfg     PressureConfig
+	readMem func() (used, limit uint64) // readMemory, replaced in tests
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+func (w *pressureWatch) run() {
+	defer close(w.done)
+	t := time.NewTicker(w.cfg.Interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-t.C:
+			w.check()
+		}
+	}
+}
+
+// check enters or leaves the pressure state, the pools are drained when entering it.
+func (w *pressureWatch) check() {
+	used, limit := w.readMem()
+	on := limit > 0 && float64(used) >= w.cfg.Fraction*float64(limit)
+	if w.p.setFlag(flagPressure, on) && on {
+		w.p.Drain()
+	}
+}
+
+func (w *pressureWatch) close() {
+	close(w.stop)
+	<-w.done
+}
+
+// StartPressureWatch starts a background goroutine watching the memory used by the Go runtime
+// (through runtime/metrics) against the memory limit set by debug.SetMemoryLimit.
+// Above cfg.Fraction of the limit, the pools are drained and stop retaining the released byte slices,
+// they resume below it. Without a memory limit (the default) or before Go 1.19, it has no effect.
+// Starting it again replaces the running watch.
+func (p *CapacityPools) StartPressureWatch(cfg PressureConfig) {
+	p.startPressureWatch(cfg, readMemory)
+}
+
+func (p *CapacityPools) startPressureWatch(cfg PressureConfig, readMem func() (used, limit uint64)) {
+	if cfg.Fraction <= 0 {
+		cfg.Fraction = DefaultPressureFraction
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultPressureInterval
+	}
+	w := &pressureWatch{
+		p:       p,
+		cfg:     cfg,
+		readMem: readMem,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	w.check()
+
+	p.mu.Lock()
+	old := p.pressure
+	p.pressure = w
+	p.mu.Unlock()
+
+	if old != nil {
+		old.close()
+	}
+	go w.run()
+}
+
+// StopPressureWatch stops the memory pressure watch, if any, and resumes retaining.
+func (p *CapacityPools) StopPressureWatch() {
+	p.mu.Lock()
+	w := p.pressure
+	p.pressure = nil
+	p.mu.Unlock()
+
+	if w != nil {
+		w.close()
+	}
+	p.setFlag(flagPressure, false)
+}
+
+// UnderPressure reports whether the pools stopped retaining because of the memory pressure.
+func (p *CapacityPools) UnderPressure() bool {
+	return atomic.LoadUint32(&p.flags)&flagPressure != 0
+}
+
+// StartPressureWatch starts the memory pressure watch on the default pools.
+func StartPressureWatch(cfg PressureConfig) {
+	Default().StartPressureWatch(cfg)
+}
+
+// StopPressureWatch stops the memory pressure watch on the default pools.
+func StopPressureWatch() {
+	Default().StopPressureWatch()
+}
+
+// Drain immediately frees the cached byte slices of the default pools.
+func Drain() int {
+	return Default().Drain()
+}
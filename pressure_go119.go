@@ -0,0 +1,34 @@
+//go:build go1.19
+// +build go1.19
+
+package bytespool
+
+import (
+	"math"
+	"runtime/debug"
+	"runtime/metrics"
+)
+
+var memoryMetrics = []string{
+	"/memory/classes/total:bytes",
+	"/memory/classes/heap/released:bytes",
+}
+
+// readMemory returns the memory mapped by the Go runtime and not released to the OS,
+// which is what the memory limit applies to, and the limit, 0 if none.
+func readMemory() (used, limit uint64) {
+	l := debug.SetMemoryLimit(-1)
+	if l <= 0 || l == math.MaxInt64 {
+		return 0, 0
+	}
+	samples := make([]metrics.Sample, len(memoryMetrics))
+	for i, name := range memoryMetrics {
+		samples[i].Name = name
+	}
+	metrics.Read(samples)
+	total, released := samples[0].Value.Uint64(), samples[1].Value.Uint64()
+	if released < total {
+		used = total - released
+	}
+	return used, uint64(l)
+}
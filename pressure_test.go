@@ -0,0 +1,86 @@
+package bytespool
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCapacityPools_Drain(t *testing.T) {
+	skipNoPool(t)
+	b := &countingBackend{}
+	p := NewCapacityPools(8, 64)
+	p.SetWithStats(true)
+	p.SetBackend(b)
+	for _, size := range []int{16, 32, 64} {
+		p.Release(p.New(size))
+	}
+	if n := p.Drain(); n != 112 || b.frees != 3 {
+		t.Fatalf("expect all classes are drained, but got %d bytes, %d frees", n, b.frees)
+	}
+	if n := p.Drain(); n != 0 {
+		t.Fatalf("expect nothing to drain, but got %d bytes", n)
+	}
+	if sum := RuntimeStatsSummary(0, p); sum.TrimmedBytes != 112 || sum.TrimmedCount != 3 {
+		t.Fatalf("unexpected trimmed stats: %+v", sum)
+	}
+	if p.Closed() {
+		t.Fatal("expect the pools are still usable after Drain")
+	}
+}
+
+func TestCapacityPools_PressureWatch(t *testing.T) {
+	skipNoPool(t)
+	var used uint64
+	readMem := func() (uint64, uint64) {
+		return atomic.LoadUint64(&used), 1000
+	}
+	b := &countingBackend{}
+	p := NewCapacityPools(8, 64)
+	p.SetWithStats(true)
+	p.SetBackend(b)
+	p.Release(p.New(16))
+
+	// above the fraction on start: drained and not retaining
+	atomic.StoreUint64(&used, 950)
+	p.startPressureWatch(PressureConfig{Interval: 5 * time.Millisecond}, readMem)
+	defer p.StopPressureWatch()
+	if !p.UnderPressure() || b.frees != 1 {
+		t.Fatalf("expect the pools are drained under pressure, but got %d frees", b.frees)
+	}
+	if p.Release(p.New(16)) {
+		t.Fatal("expect the release is discarded under pressure")
+	}
+
+	// below the fraction: retaining again
+	atomic.StoreUint64(&used, 100)
+	deadline := time.Now().Add(2 * time.Second)
+	for p.UnderPressure() {
+		if time.Now().After(deadline) {
+			t.Fatal("expect the pressure is gone")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !p.Release(p.New(16)) {
+		t.Fatal("expect the release is retained without pressure")
+	}
+
+	atomic.StoreUint64(&used, 950)
+	p.StopPressureWatch()
+	p.StopPressureWatch()
+	if p.UnderPressure() {
+		t.Fatal("expect no pressure after the watch is stopped")
+	}
+}
+
+func TestStartPressureWatch(t *testing.T) {
+	if _, limit := readMemory(); limit != 0 {
+		t.Skip("a memory limit is set")
+	}
+	// without a memory limit the pools keep retaining
+	StartPressureWatch(PressureConfig{Fraction: 0.5})
+	defer StopPressureWatch()
+	if Default().UnderPressure() {
+		t.Fatal("expect no pressure without a memory limit")
+	}
+}
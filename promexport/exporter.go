@@ -0,0 +1,361 @@
+// Package promexport writes bytespool statistics in the Prometheus text exposition format.
+// It has no dependencies outside the standard library.
+package promexport
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/fufuok/bytespool"
+	// registers the buffer pools
+	_ "github.com/fufuok/bytespool/buffer"
+)
+
+const (
+	// ContentType is the content type of the Prometheus text format.
+	ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+	// OpenMetricsContentType is the content type of the OpenMetrics text format.
+	OpenMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+	// DefaultNamespace is the default prefix of the metric names.
+	DefaultNamespace = "bytespool"
+
+	// DefaultPoolName is the pool label of bytespool.Default().
+	DefaultPoolName = bytespool.DefaultPoolName
+
+	// BufferPoolName is the pool label of the buffer package pools.
+	BufferPoolName = bytespool.BufferPoolName
+)
+
+var _ http.Handler = (*Exporter)(nil)
+
+// Exporter collects the statistics of a set of named CapacityPools.
+// The statistics collection of each pool must be enabled with SetWithStats,
+// otherwise all its counters are zero.
+type Exporter struct {
+	namespace string
+	pools     *bytespool.PoolSet
+}
+
+// New returns an Exporter of every registered pool, including the default pools and the buffer pools,
+// followed by the added pools, see bytespool.NewPoolSet.
+func New() *Exporter {
+	return &Exporter{namespace: DefaultNamespace, pools: bytespool.NewPoolSet(true)}
+}
+
+// NewEmpty returns an Exporter without any pools.
+func NewEmpty() *Exporter {
+	return &Exporter{namespace: DefaultNamespace, pools: bytespool.NewPoolSet(false)}
+}
+
+// SetNamespace sets the prefix of the metric names, "bytespool" by default.
+// This function is not thread-safe and should be called before the first scrape.
+func (e *Exporter) SetNamespace(ns string) *Exporter {
+	e.namespace = ns
+	return e
+}
+
+// Add registers p under the pool label name.
+func (e *Exporter) Add(name string, p *bytespool.CapacityPools) *Exporter {
+	return e.AddFunc(name, func() *bytespool.CapacityPools { return p })
+}
+
+// AddFunc registers a pool resolved at every scrape under the pool label name.
+// Registering an existing name replaces it.
+func (e *Exporter) AddFunc(name string, fn func() *bytespool.CapacityPools) *Exporter {
+	e.pools.AddFunc(name, fn)
+	return e
+}
+
+// AddStats registers a pool of another kind, e.g. a bytespool.SlicePool, under the pool label name.
+// Its class capacities are in elements.
+func (e *Exporter) AddStats(name string, p bytespool.StatsPool) *Exporter {
+	e.pools.AddStats(name, p)
+	return e
+}
+
+// Remove unregisters the pool label name.
+func (e *Exporter) Remove(name string) {
+	e.pools.Remove(name)
+}
+
+// ServeHTTP implements http.Handler.
+// The OpenMetrics format is used when the scraper accepts it.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	openMetrics := strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text")
+	if openMetrics {
+		w.Header().Set("Content-Type", OpenMetricsContentType)
+	} else {
+		w.Header().Set("Content-Type", ContentType)
+	}
+	_, _ = e.write(w, openMetrics)
+}
+
+// WriteTo writes all statistics in the Prometheus text format to w.
+func (e *Exporter) WriteTo(w io.Writer) (int64, error) {
+	return e.write(w, false)
+}
+
+// WriteOpenMetrics writes all statistics in the OpenMetrics text format to w.
+func (e *Exporter) WriteOpenMetrics(w io.Writer) (int64, error) {
+	return e.write(w, true)
+}
+
+type poolData struct {
+	name    string
+	p       bytespool.StatsPool
+	summary bytespool.RuntimeSummary
+	classes []bytespool.PoolStat
+}
+
+func (e *Exporter) collect() []poolData {
+	var data []poolData
+	e.pools.RangeStats(func(name string, p bytespool.StatsPool) bool {
+		data = append(data, poolData{
+			name:    name,
+			p:       p,
+			summary: p.StatsSummaryBy(0, bytespool.SortByReuseHits),
+			classes: p.PoolStats(),
+		})
+		return true
+	})
+	return data
+}
+
+type family struct {
+	name string
+	typ  string
+	help string
+	// samples appends the samples of one pool.
+	samples func(mw *metricWriter, name string, d *poolData)
+}
+
+func (e *Exporter) families() []family {
+	counter := func(name, help string, v func(s *bytespool.RuntimeSummary) uint64) family {
+		return family{name: name, typ: "counter", help: help, samples: func(mw *metricWriter, n string, d *poolData) {
+			mw.sample(n, v(&d.summary), "pool", d.name)
+		}}
+	}
+	class := func(name, help string, v func(st *bytespool.PoolStat) uint64) family {
+		return family{name: name, typ: "counter", help: help, samples: func(mw *metricWriter, n string, d *poolData) {
+			for i := range d.classes {
+				st := &d.classes[i]
+				mw.sample(n, v(st), "pool", d.name, "class", strconv.Itoa(st.Capacity))
+			}
+		}}
+	}
+	tag := func(name, typ, help string, v func(st *bytespool.TagStat) uint64) family {
+		return family{name: name, typ: typ, help: help, samples: func(mw *metricWriter, n string, d *poolData) {
+			for i := range d.summary.Tags {
+				st := &d.summary.Tags[i]
+				mw.sample(n, v(st), "pool", d.name, "tag", st.Tag)
+			}
+		}}
+	}
+	lifetime := func(name, help string, v func(st *bytespool.LifetimeStat) float64) family {
+		return family{name: name, typ: "gauge", help: help, samples: func(mw *metricWriter, n string, d *poolData) {
+			for i := range d.summary.Lifetimes {
+				st := &d.summary.Lifetimes[i]
+				mw.sampleFloat(n, v(st), "pool", d.name, "class", strconv.Itoa(st.Capacity))
+			}
+		}}
+	}
+	rate := func(name, help string, v func(r *bytespool.Rate) float64) family {
+		return family{name: name, typ: "gauge", help: help, samples: func(mw *metricWriter, n string, d *poolData) {
+			for i := range d.summary.Rates {
+				r := &d.summary.Rates[i]
+				mw.sampleFloat(n, v(r), "pool", d.name, "window", r.Window.String())
+			}
+		}}
+	}
+	return []family{
+		{name: "stats_enabled", typ: "gauge", help: "Whether statistics collection is enabled for the pool.",
+			samples: func(mw *metricWriter, n string, d *poolData) {
+				v := uint64(0)
+				if d.p.GetWithStats() {
+					v = 1
+				}
+				mw.sample(n, v, "pool", d.name)
+			}},
+		{name: "min_size_bytes", typ: "gauge", help: "Smallest pooled capacity.",
+			samples: func(mw *metricWriter, n string, d *poolData) {
+				mw.sample(n, uint64(d.p.MinSize()), "pool", d.name)
+			}},
+		{name: "max_size_bytes", typ: "gauge", help: "Largest pooled capacity.",
+			samples: func(mw *metricWriter, n string, d *poolData) {
+				mw.sample(n, uint64(d.p.MaxSize()), "pool", d.name)
+			}},
+		counter("new_total", "Byte slices newly allocated for the pool.",
+			func(s *bytespool.RuntimeSummary) uint64 { return s.NewCount }),
+		counter("new_bytes_total", "Bytes newly allocated for the pool.",
+			func(s *bytespool.RuntimeSummary) uint64 { return s.NewBytes }),
+		counter("reused_total", "Byte slices reused from the pool.",
+			func(s *bytespool.RuntimeSummary) uint64 { return s.ReusedCount }),
+		counter("reused_bytes_total", "Bytes reused from the pool.",
+			func(s *bytespool.RuntimeSummary) uint64 { return s.ReusedBytes }),
+		counter("out_total", "Byte slices allocated outside the pool because they are out of range.",
+			func(s *bytespool.RuntimeSummary) uint64 { return s.OutCount }),
+		counter("out_bytes_total", "Bytes allocated outside the pool because they are out of range.",
+			func(s *bytespool.RuntimeSummary) uint64 { return s.OutBytes }),
+		counter("released_total", "Byte slices put back into the pool.",
+			func(s *bytespool.RuntimeSummary) uint64 { return s.ReleasedCount }),
+		counter("discarded_total", "Byte slices discarded by Release because they are out of range, or the pools are closed or under memory pressure.",
+			func(s *bytespool.RuntimeSummary) uint64 { return s.DiscardCount }),
+		counter("trimmed_total", "Cached byte slices freed by Trim, Drain, the janitor or the memory pressure watcher.",
+			func(s *bytespool.RuntimeSummary) uint64 { return s.TrimmedCount }),
+		counter("trimmed_bytes_total", "Bytes of the cached byte slices freed by Trim, Drain, the janitor or the memory pressure watcher.",
+			func(s *bytespool.RuntimeSummary) uint64 { return s.TrimmedBytes }),
+		counter("overflow_hits_total", "Byte slices larger than the largest class reused from the overflow tier.",
+			func(s *bytespool.RuntimeSummary) uint64 { return s.OverflowHits }),
+		counter("overflow_hit_bytes_total", "Capacity bytes reused from the overflow tier.",
+			func(s *bytespool.RuntimeSummary) uint64 { return s.OverflowBytes }),
+		counter("overflow_evictions_total", "Byte slices evicted from the overflow tier.",
+			func(s *bytespool.RuntimeSummary) uint64 { return s.OverflowEvict }),
+		{name: "overflow_cached_bytes", typ: "gauge", help: "Capacity bytes cached by the overflow tier.",
+			samples: func(mw *metricWriter, n string, d *poolData) {
+				mw.sample(n, d.summary.OverflowCache, "pool", d.name)
+			}},
+		{name: "outstanding", typ: "gauge", help: "Pooled byte slices not yet released.",
+			samples: func(mw *metricWriter, n string, d *poolData) {
+				mw.sample(n, d.summary.Outstanding, "pool", d.name)
+			}},
+		class("class_reuse_hits_total", "Byte slices reused from the size class.",
+			func(st *bytespool.PoolStat) uint64 { return st.ReuseHits }),
+		class("class_misses_total", "Byte slices newly allocated for the size class.",
+			func(st *bytespool.PoolStat) uint64 { return st.Misses }),
+		class("class_bytes_total", "Capacity bytes handed out from the size class.",
+			func(st *bytespool.PoolStat) uint64 { return st.Bytes }),
+		class("class_waste_bytes_total", "Capacity bytes handed out from the size class but not requested.",
+			func(st *bytespool.PoolStat) uint64 { return st.Waste }),
+		{name: "class_outstanding", typ: "gauge", help: "Byte slices of the size class not yet released.",
+			samples: func(mw *metricWriter, n string, d *poolData) {
+				for i := range d.classes {
+					st := &d.classes[i]
+					mw.sample(n, st.Outstanding, "pool", d.name, "class", strconv.Itoa(st.Capacity))
+				}
+			}},
+		tag("tag_gets_total", "counter", "Byte slices acquired with the tag.",
+			func(st *bytespool.TagStat) uint64 { return st.Gets }),
+		tag("tag_bytes_total", "counter", "Capacity bytes acquired with the tag.",
+			func(st *bytespool.TagStat) uint64 { return st.Bytes }),
+		tag("tag_outstanding", "gauge", "Byte slices acquired with the tag and not yet released.",
+			func(st *bytespool.TagStat) uint64 { return st.Outstanding }),
+		tag("tag_outstanding_bytes", "gauge", "Capacity bytes acquired with the tag and not yet released.",
+			func(st *bytespool.TagStat) uint64 { return st.OutstandingBytes }),
+		lifetime("lifetime_p50_seconds", "Estimated median hold duration of the size class.",
+			func(st *bytespool.LifetimeStat) float64 { return st.P50.Seconds() }),
+		lifetime("lifetime_p99_seconds", "Estimated 99th percentile hold duration of the size class.",
+			func(st *bytespool.LifetimeStat) float64 { return st.P99.Seconds() }),
+		lifetime("lifetime_max_seconds", "Longest hold duration of the size class.",
+			func(st *bytespool.LifetimeStat) float64 { return st.Max.Seconds() }),
+		rate("allocs_per_second", "Byte slices handed out per second over the window.",
+			func(r *bytespool.Rate) float64 { return r.AllocsPerSec }),
+		rate("out_per_second", "Byte slices allocated outside the pool per second over the window.",
+			func(r *bytespool.Rate) float64 { return r.OutPerSec }),
+		rate("reuse_ratio", "Ratio of byte slices reused from the pool over the window.",
+			func(r *bytespool.Rate) float64 { return r.ReuseRatio }),
+	}
+}
+
+func (e *Exporter) write(w io.Writer, openMetrics bool) (int64, error) {
+	mw := &metricWriter{w: bufio.NewWriter(w)}
+	data := e.collect()
+	for _, f := range e.families() {
+		name := f.name
+		if e.namespace != "" {
+			name = e.namespace + "_" + name
+		}
+		typeName := name
+		if openMetrics && f.typ == "counter" {
+			typeName = strings.TrimSuffix(name, "_total")
+		}
+		mw.writeString("# HELP " + typeName + " " + f.help + "\n")
+		mw.writeString("# TYPE " + typeName + " " + f.typ + "\n")
+		for i := range data {
+			f.samples(mw, name, &data[i])
+		}
+	}
+	if openMetrics {
+		mw.writeString("# EOF\n")
+	}
+	if mw.err == nil {
+		mw.err = mw.w.Flush()
+	}
+	return mw.n, mw.err
+}
+
+// metricWriter writes samples and keeps the first error.
+type metricWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+	buf []byte
+}
+
+func (mw *metricWriter) writeString(s string) {
+	if mw.err != nil {
+		return
+	}
+	n, err := mw.w.WriteString(s)
+	mw.n += int64(n)
+	mw.err = err
+}
+
+func (mw *metricWriter) sample(name string, v uint64, labels ...string) {
+	mw.buf = strconv.AppendUint(mw.appendName(name, labels), v, 10)
+	mw.flushLine()
+}
+
+func (mw *metricWriter) sampleFloat(name string, v float64, labels ...string) {
+	mw.buf = strconv.AppendFloat(mw.appendName(name, labels), v, 'g', -1, 64)
+	mw.flushLine()
+}
+
+func (mw *metricWriter) appendName(name string, labels []string) []byte {
+	b := append(mw.buf[:0], name...)
+	if len(labels) > 0 {
+		b = append(b, '{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = append(b, labels[i]...)
+			b = append(b, '=', '"')
+			b = appendLabelValue(b, labels[i+1])
+			b = append(b, '"')
+		}
+		b = append(b, '}')
+	}
+	return append(b, ' ')
+}
+
+func (mw *metricWriter) flushLine() {
+	if mw.err != nil {
+		return
+	}
+	mw.buf = append(mw.buf, '\n')
+	n, err := mw.w.Write(mw.buf)
+	mw.n += int64(n)
+	mw.err = err
+}
+
+// appendLabelValue escapes backslash, double-quote and line feed.
+func appendLabelValue(b []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\':
+			b = append(b, '\\', '\\')
+		case '"':
+			b = append(b, '\\', '"')
+		case '\n':
+			b = append(b, '\\', 'n')
+		default:
+			b = append(b, c)
+		}
+	}
+	return b
+}
@@ -0,0 +1,137 @@
+package promexport
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fufuok/bytespool"
+	"github.com/fufuok/bytespool/buffer"
+)
+
+func TestExporter_ServeHTTP(t *testing.T) {
+	if bytespool.NoPool {
+		t.Skip("byte slices are not reused with the bytespool_nopool tag")
+	}
+	p := bytespool.NewCapacityPools(8, 64)
+	p.SetWithStats(true)
+	p.SetWithLifetime(true)
+	p.Release(p.New(20))
+	_ = p.New(20)
+	_ = p.New(100)
+	p.ObserveLifetime(32, p.LifetimeStamp())
+
+	e := NewEmpty().Add(`api"v1`, p)
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != ContentType {
+		t.Fatalf("unexpected content type: %s", ct)
+	}
+
+	out := string(body)
+	wants := []string{
+		"# TYPE bytespool_new_total counter\n",
+		`bytespool_stats_enabled{pool="api\"v1"} 1` + "\n",
+		`bytespool_out_bytes_total{pool="api\"v1"} 100` + "\n",
+		`bytespool_class_waste_bytes_total{pool="api\"v1",class="32"} 24` + "\n",
+		`bytespool_class_misses_total{pool="api\"v1",class="8"} 0` + "\n",
+		`bytespool_max_size_bytes{pool="api\"v1"} 64` + "\n",
+		`bytespool_outstanding{pool="api\"v1"} 1` + "\n",
+		`bytespool_lifetime_max_seconds{pool="api\"v1",class="32"} `,
+		`bytespool_class_outstanding{pool="api\"v1",class="32"} 1` + "\n",
+	}
+	if !raceEnabled {
+		wants = append(wants,
+			`bytespool_new_total{pool="api\"v1"} 1`+"\n",
+			`bytespool_reused_bytes_total{pool="api\"v1"} 32`+"\n",
+			`bytespool_class_reuse_hits_total{pool="api\"v1",class="32"} 1`+"\n",
+		)
+	}
+	for _, want := range wants {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "# EOF") || strings.Contains(out, "per_second{") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+}
+
+func TestExporter_OpenMetrics(t *testing.T) {
+	p := bytespool.NewCapacityPools(8, 64)
+	p.SetWithStats(true)
+	p.StartSampler(time.Second, time.Minute)
+	defer p.StopSampler()
+
+	e := NewEmpty().SetNamespace("app").Add("x", p)
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	if ct := rec.Header().Get("Content-Type"); ct != OpenMetricsContentType {
+		t.Fatalf("unexpected content type: %s", ct)
+	}
+	out := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE app_new counter\n",
+		"app_new_total{pool=\"x\"} 0\n",
+		"app_reuse_ratio{pool=\"x\",window=\"1m0s\"} 0\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if !strings.HasSuffix(out, "# EOF\n") {
+		t.Fatal("expect the output ends with # EOF")
+	}
+}
+
+func TestExporter_Pools(t *testing.T) {
+	e := New()
+	var buf bytes.Buffer
+	n, err := e.WriteTo(&buf)
+	if err != nil || n != int64(buf.Len()) {
+		t.Fatalf("unexpected result: %d, %v", n, err)
+	}
+	// the buffer pools are the default pools unless buffer.SetCapacity is called
+	if strings.Contains(buf.String(), `pool="buffer"`) || !strings.Contains(buf.String(), `pool="default"`) {
+		t.Fatalf("unexpected pools:\n%s", buf.String())
+	}
+
+	// pools registered after New are discovered at scrape time
+	p := bytespool.NewCapacityPools(8, 64)
+	_ = bytespool.Register("exporter-test", p)
+	buf.Reset()
+	_, _ = e.WriteTo(&buf)
+	bytespool.Unregister("exporter-test")
+	if !strings.Contains(buf.String(), `pool="exporter-test"`) {
+		t.Fatalf("expect the registered pools, but got:\n%s", buf.String())
+	}
+
+	e = NewEmpty().Add("a", p).AddFunc(BufferPoolName, buffer.Pools)
+	buf.Reset()
+	_, _ = e.WriteTo(&buf)
+	if strings.Contains(buf.String(), `pool="default"`) || !strings.Contains(buf.String(), `pool="buffer"`) {
+		t.Fatalf("expect the added pools only, but got:\n%s", buf.String())
+	}
+
+	e.Remove("a")
+	e.Remove(BufferPoolName)
+	buf.Reset()
+	_, _ = e.WriteOpenMetrics(&buf)
+	if strings.Contains(buf.String(), "pool=") {
+		t.Fatalf("expect no samples, but got:\n%s", buf.String())
+	}
+}
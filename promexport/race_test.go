@@ -0,0 +1,8 @@
+//go:build race
+// +build race
+
+package promexport
+
+// raceEnabled reports whether the tests run with the race detector, which
+// makes sync.Pool drop objects at random, so the reuse counts are not exact.
+const raceEnabled = true
@@ -0,0 +1,158 @@
+package bytespool
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// ErrReadTooLarge is returned when the data read exceeds ReadConfig.MaxSize.
+var ErrReadTooLarge = errors.New("bytespool: read exceeds the maximum size")
+
+// minRead is the initial size without a size hint, like bytes.MinRead.
+const minRead = 512
+
+// ReadConfig configures ReadAllWith.
+type ReadConfig struct {
+	// SizeHint is the expected size, e.g. the Content-Length of a response.
+	// If 0, the Len() or Stat() size of the reader is used when available.
+	SizeHint int
+	// MaxSize is the maximum size to read, ErrReadTooLarge is returned beyond it. 0 for no limit.
+	MaxSize int
+}
+
+// ReadAll reads from r until EOF into a byte slice of the pools, like io.ReadAll.
+// It is sized up front if r has a Len() or Stat() method, and grows through the pools otherwise.
+// The data read is returned even on error, release it anyway.
+func (p *CapacityPools) ReadAll(r io.Reader) ([]byte, error) {
+	return p.ReadAllWith(r, ReadConfig{})
+}
+
+// ReadAllWith is ReadAll with a size hint and a maximum size.
+// If the data exceeds cfg.MaxSize, the first MaxSize bytes are returned with ErrReadTooLarge.
+func (p *CapacityPools) ReadAllWith(r io.Reader, cfg ReadConfig) ([]byte, error) {
+	hint := cfg.SizeHint
+	if hint <= 0 {
+		hint = sizeHint(r)
+	}
+	size := hint
+	if size <= 0 {
+		size = minRead
+	}
+	if cfg.MaxSize > 0 && size > cfg.MaxSize {
+		size = cfg.MaxSize
+	}
+
+	buf := p.Make(size)
+	for {
+		limit := cap(buf)
+		if cfg.MaxSize > 0 && limit > cfg.MaxSize {
+			limit = cfg.MaxSize
+		}
+		if len(buf) == limit {
+			// check for more data before growing an exact fit or failing at the maximum size
+			if len(buf) == hint || len(buf) == cfg.MaxSize {
+				b, err := probe(r)
+				if err != nil {
+					if err == io.EOF {
+						err = nil
+					}
+					return buf, err
+				}
+				if len(buf) == cfg.MaxSize {
+					return buf, ErrReadTooLarge
+				}
+				buf = p.Append(buf, b)
+				continue
+			}
+			// double the capacity, by at least minRead, like bytes.Buffer
+			buf = p.grow(buf, 2*len(buf)+minRead)
+			continue
+		}
+		n, err := r.Read(buf[len(buf):limit])
+		buf = buf[:len(buf)+n]
+		if err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			return buf, err
+		}
+	}
+}
+
+// probe reads a single byte from r.
+func probe(r io.Reader) (byte, error) {
+	var b [1]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+// ReadN reads exactly n bytes from r into a byte slice of the pools, like io.ReadFull.
+// On error, the byte slice is released and nil is returned with io.EOF or io.ErrUnexpectedEOF.
+func (p *CapacityPools) ReadN(r io.Reader, n int) ([]byte, error) {
+	buf := p.New(n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		p.Release(buf)
+		return nil, err
+	}
+	return buf, nil
+}
+
+// ReadFile reads the named file into a byte slice of the pools, like os.ReadFile.
+// It is sized up front by Stat. The data read is returned even on error, release it anyway.
+func (p *CapacityPools) ReadFile(name string) ([]byte, error) {
+	return p.ReadFileWith(name, ReadConfig{})
+}
+
+// ReadFileWith is ReadFile with a maximum size, see ReadAllWith.
+func (p *CapacityPools) ReadFileWith(name string, cfg ReadConfig) ([]byte, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return p.ReadAllWith(f, cfg)
+}
+
+// sizeHint returns the size of the data of r if known, 0 otherwise.
+func sizeHint(r io.Reader) int {
+	switch v := r.(type) {
+	case interface{ Len() int }:
+		return v.Len()
+	case interface{ Stat() (os.FileInfo, error) }:
+		if fi, err := v.Stat(); err == nil && fi.Mode().IsRegular() && fi.Size() < maxHint {
+			return int(fi.Size())
+		}
+	}
+	return 0
+}
+
+// maxHint bounds the size hints, the pools handle byte slices up to math.MaxInt32.
+const maxHint = 1<<31 - 1
+
+// ReadAll reads from r until EOF into a byte slice of the default pools.
+func ReadAll(r io.Reader) ([]byte, error) {
+	return Default().ReadAll(r)
+}
+
+// ReadAllWith is ReadAll with a size hint and a maximum size.
+func ReadAllWith(r io.Reader, cfg ReadConfig) ([]byte, error) {
+	return Default().ReadAllWith(r, cfg)
+}
+
+// ReadN reads exactly n bytes from r into a byte slice of the default pools.
+func ReadN(r io.Reader, n int) ([]byte, error) {
+	return Default().ReadN(r, n)
+}
+
+// ReadFile reads the named file into a byte slice of the default pools.
+func ReadFile(name string) ([]byte, error) {
+	return Default().ReadFile(name)
+}
+
+// ReadFileWith is ReadFile with a maximum size.
+func ReadFileWith(name string, cfg ReadConfig) ([]byte, error) {
+	return Default().ReadFileWith(name, cfg)
+}
@@ -0,0 +1,131 @@
+package bytespool
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// onlyReader hides the Len method of the underlying reader.
+type onlyReader struct {
+	io.Reader
+}
+
+func TestCapacityPools_ReadAll(t *testing.T) {
+	p := NewCapacityPools(2, 1<<20)
+	p.SetWithStats(true)
+	data := strings.Repeat("0123456789", 1000)
+
+	// sized by Len
+	buf, err := p.ReadAll(strings.NewReader(data))
+	if err != nil || string(buf) != data || cap(buf) != 16384 {
+		t.Fatalf("expect the data in cap 16384, but got cap %d, %v", cap(buf), err)
+	}
+	p.Release(buf)
+	if sum := RuntimeStatsSummary(0, p); sum.NewCount+sum.ReusedCount != 1 {
+		t.Fatalf("expect a single allocation, but got: %+v", sum)
+	}
+
+	// grows through the pools
+	buf, err = p.ReadAll(onlyReader{strings.NewReader(data)})
+	if err != nil || string(buf) != data {
+		t.Fatalf("unexpected result: %v", err)
+	}
+	p.Release(buf)
+	if sum := RuntimeStatsSummary(0, p); sum.Outstanding != 0 {
+		t.Fatalf("expect the grown byte slices are released, but got: %+v", sum)
+	}
+
+	// exact fit of a power of two, wrong hint
+	for _, hint := range []int{1024, 10, 50000} {
+		buf, err = p.ReadAllWith(onlyReader{strings.NewReader(data[:1024])}, ReadConfig{SizeHint: hint})
+		if err != nil || string(buf) != data[:1024] {
+			t.Fatalf("unexpected result with hint %d: %v", hint, err)
+		}
+		p.Release(buf)
+	}
+
+	buf, err = p.ReadAll(onlyReader{strings.NewReader("")})
+	if err != nil || len(buf) != 0 {
+		t.Fatalf("expect an empty byte slice, but got %d, %v", len(buf), err)
+	}
+
+	errRead := errors.New("read error")
+	buf, err = p.ReadAll(io.MultiReader(strings.NewReader("abc"), &errReader{errRead}))
+	if err != errRead || string(buf) != "abc" {
+		t.Fatalf("expect the data read and the error, but got %q, %v", buf, err)
+	}
+}
+
+type errReader struct {
+	err error
+}
+
+func (r *errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+func TestCapacityPools_ReadAllMaxSize(t *testing.T) {
+	p := NewCapacityPools(2, 1<<20)
+	data := strings.Repeat("x", 5000)
+	for _, r := range []io.Reader{strings.NewReader(data), onlyReader{strings.NewReader(data)}} {
+		buf, err := p.ReadAllWith(r, ReadConfig{MaxSize: 4096})
+		if err != ErrReadTooLarge || len(buf) != 4096 {
+			t.Fatalf("expect ErrReadTooLarge, but got %d, %v", len(buf), err)
+		}
+	}
+	buf, err := p.ReadAllWith(onlyReader{strings.NewReader(data)}, ReadConfig{MaxSize: 5000})
+	if err != nil || string(buf) != data {
+		t.Fatalf("expect the data within the maximum size, but got %d, %v", len(buf), err)
+	}
+}
+
+func TestCapacityPools_ReadN(t *testing.T) {
+	p := NewCapacityPools(2, 64)
+	buf, err := p.ReadN(bytes.NewReader([]byte("abcdef")), 4)
+	if err != nil || string(buf) != "abcd" || cap(buf) != 4 {
+		t.Fatalf("expect abcd, but got %q, %v", buf, err)
+	}
+	if buf, err = p.ReadN(strings.NewReader("ab"), 4); err != io.ErrUnexpectedEOF || buf != nil {
+		t.Fatalf("expect io.ErrUnexpectedEOF, but got %q, %v", buf, err)
+	}
+	if buf, err = ReadN(strings.NewReader("ab"), 2); err != nil || string(buf) != "ab" {
+		t.Fatalf("expect ab, but got %q, %v", buf, err)
+	}
+}
+
+func TestCapacityPools_ReadFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bytespool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "data")
+	data := strings.Repeat("0123456789", 100)
+	if err = ioutil.WriteFile(name, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewCapacityPools(2, 1<<20)
+	buf, err := p.ReadFile(name)
+	if err != nil || string(buf) != data || cap(buf) != 1024 {
+		t.Fatalf("expect the file content in cap 1024, but got cap %d, %v", cap(buf), err)
+	}
+	if _, err = ReadFileWith(name, ReadConfig{MaxSize: 10}); err != ErrReadTooLarge {
+		t.Fatalf("expect ErrReadTooLarge, but got %v", err)
+	}
+	if _, err = ReadFile(filepath.Join(dir, "missing")); !os.IsNotExist(err) {
+		t.Fatalf("expect a not exist error, but got %v", err)
+	}
+	if buf, err = ReadAll(strings.NewReader("abc")); err != nil || string(buf) != "abc" {
+		t.Fatalf("expect abc, but got %q, %v", buf, err)
+	}
+	if buf, err = ReadAllWith(strings.NewReader("abc"), ReadConfig{}); err != nil || string(buf) != "abc" {
+		t.Fatalf("expect abc, but got %q, %v", buf, err)
+	}
+}
@@ -0,0 +1,400 @@
+package bytespool
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultPoolName is the registered name of the default pools (see Default).
+	DefaultPoolName = "default"
+
+	// BufferPoolName is the registered name of the buffer package pools.
+	BufferPoolName = "buffer"
+)
+
+var (
+	ErrEmptyName     = errors.New("bytespool: empty pool name")
+	ErrDuplicateName = errors.New("bytespool: pool name already registered")
+)
+
+// registry holds the named pools of the process, in registration order.
+var registry struct {
+	mu    sync.RWMutex
+	pools []registeredPools
+}
+
+type registeredPools struct {
+	name  string
+	get   func() *CapacityPools
+	stats StatsPool // Set instead of get by RegisterStats
+}
+
+// StatsPool is a pool exposing its statistics to the registry and the exporters,
+// implemented by CapacityPools and SlicePool.
+type StatsPool interface {
+	GetWithStats() bool
+	MinSize() int
+	MaxSize() int
+	StatsSummaryBy(topN int, by SortBy) RuntimeSummary
+	PoolStats() []PoolStat
+}
+
+var _ StatsPool = (*CapacityPools)(nil)
+
+// pool returns the pools of rp, nil if none.
+func (rp registeredPools) pool() StatsPool {
+	if rp.get == nil {
+		return rp.stats
+	}
+	if p := rp.get(); p != nil {
+		return p
+	}
+	return nil
+}
+
+func init() {
+	_ = RegisterFunc(DefaultPoolName, func() *CapacityPools {
+		return Default()
+	})
+}
+
+// Register registers p under name, so that exporters and debug handlers can discover it.
+// It returns ErrDuplicateName if name is already registered.
+func Register(name string, p *CapacityPools) error {
+	return RegisterFunc(name, func() *CapacityPools { return p })
+}
+
+// RegisterFunc registers a pool resolved at every lookup under name,
+// for pools that may be replaced, like the default pools.
+// It returns ErrDuplicateName if name is already registered.
+func RegisterFunc(name string, fn func() *CapacityPools) error {
+	return register(registeredPools{name: name, get: fn})
+}
+
+// RegisterStats registers a pool of another kind than CapacityPools under name, e.g. a SlicePool,
+// so that AggregateStatsSummary and the exporters include its statistics.
+// It is only visited by RangeStats, not by RangePools or Lookup, unless s is a CapacityPools.
+// It returns ErrDuplicateName if name is already registered.
+func RegisterStats(name string, s StatsPool) error {
+	if p, ok := s.(*CapacityPools); ok {
+		return Register(name, p)
+	}
+	return register(registeredPools{name: name, stats: s})
+}
+
+func register(rp registeredPools) error {
+	if rp.name == "" {
+		return ErrEmptyName
+	}
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	for _, r := range registry.pools {
+		if r.name == rp.name {
+			return ErrDuplicateName
+		}
+	}
+	registry.pools = append(registry.pools, rp)
+	return nil
+}
+
+// Unregister removes name from the registry.
+func Unregister(name string) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	for i, rp := range registry.pools {
+		if rp.name == name {
+			registry.pools = append(registry.pools[:i], registry.pools[i+1:]...)
+			return
+		}
+	}
+}
+
+// Lookup returns the pools registered under name, nil if none.
+func Lookup(name string) *CapacityPools {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	for _, rp := range registry.pools {
+		if rp.name == name && rp.get != nil {
+			return rp.get()
+		}
+	}
+	return nil
+}
+
+// RangePools calls fn for every registered pool in registration order, until fn returns false.
+// A pool registered under several names (e.g. the buffer pools are the default pools
+// unless buffer.SetCapacity is called) is only visited under its first name.
+// fn must not register or unregister pools.
+func RangePools(fn func(name string, p *CapacityPools) bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	rangeNamed(registry.pools, fn)
+}
+
+// RangeStats is RangePools also visiting the pools registered with RegisterStats.
+func RangeStats(fn func(name string, s StatsPool) bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	rangeNamedStats(registry.pools, fn)
+}
+
+// rangeNamed calls fn for every CapacityPools of rps in order, skipping nil and already visited pools.
+func rangeNamed(rps []registeredPools, fn func(name string, p *CapacityPools) bool) {
+	rangeNamedStats(rps, func(name string, s StatsPool) bool {
+		p, ok := s.(*CapacityPools)
+		return !ok || fn(name, p)
+	})
+}
+
+// rangeNamedStats calls fn for every pool of rps in order, skipping nil and already visited pools.
+func rangeNamedStats(rps []registeredPools, fn func(name string, s StatsPool) bool) {
+	seen := make(map[StatsPool]bool, len(rps))
+	for _, rp := range rps {
+		s := rp.pool()
+		if s == nil || seen[s] {
+			continue
+		}
+		seen[s] = true
+		if !fn(rp.name, s) {
+			return
+		}
+	}
+}
+
+// PoolSet is a set of named pools collected by the exporters and debug handlers:
+// the registered pools if it includes the registry, followed by the added pools.
+// It is safe for concurrent use.
+type PoolSet struct {
+	registry bool
+
+	mu    sync.RWMutex
+	pools []registeredPools
+}
+
+// NewPoolSet returns an empty PoolSet, which also includes every registered pool,
+// resolved at every Range, if registry is true.
+func NewPoolSet(registry bool) *PoolSet {
+	return &PoolSet{registry: registry}
+}
+
+// Add adds p under name.
+func (s *PoolSet) Add(name string, p *CapacityPools) {
+	s.AddFunc(name, func() *CapacityPools { return p })
+}
+
+// AddFunc adds a pool resolved at every Range under name.
+// Adding an existing name replaces it.
+func (s *PoolSet) AddFunc(name string, fn func() *CapacityPools) {
+	s.add(registeredPools{name: name, get: fn})
+}
+
+// AddStats adds a pool of another kind than CapacityPools under name, e.g. a SlicePool,
+// only visited by RangeStats, see RegisterStats.
+func (s *PoolSet) AddStats(name string, sp StatsPool) {
+	if p, ok := sp.(*CapacityPools); ok {
+		s.Add(name, p)
+		return
+	}
+	s.add(registeredPools{name: name, stats: sp})
+}
+
+func (s *PoolSet) add(rp registeredPools) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.pools {
+		if s.pools[i].name == rp.name {
+			s.pools[i] = rp
+			return
+		}
+	}
+	s.pools = append(s.pools, rp)
+}
+
+// Remove removes name from the added pools.
+func (s *PoolSet) Remove(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.pools {
+		if s.pools[i].name == name {
+			s.pools = append(s.pools[:i], s.pools[i+1:]...)
+			return
+		}
+	}
+}
+
+// Range calls fn for every pool of the set in order, until fn returns false.
+// A pool under several names is only visited under its first name.
+func (s *PoolSet) Range(fn func(name string, p *CapacityPools) bool) {
+	rangeNamed(s.snapshot(), fn)
+}
+
+// RangeStats is Range also visiting the pools added with AddStats or registered with RegisterStats.
+func (s *PoolSet) RangeStats(fn func(name string, sp StatsPool) bool) {
+	rangeNamedStats(s.snapshot(), fn)
+}
+
+// snapshot returns the registered pools if the set includes the registry, followed by the added pools.
+func (s *PoolSet) snapshot() []registeredPools {
+	var rps []registeredPools
+	if s.registry {
+		registry.mu.RLock()
+		rps = append(rps, registry.pools...)
+		registry.mu.RUnlock()
+	}
+	s.mu.RLock()
+	rps = append(rps, s.pools...)
+	s.mu.RUnlock()
+	return rps
+}
+
+// PoolNames returns the names of the registered pools, as visited by RangePools.
+func PoolNames() []string {
+	var names []string
+	RangePools(func(name string, _ *CapacityPools) bool {
+		names = append(names, name)
+		return true
+	})
+	return names
+}
+
+// AggregateStatsSummary returns the RuntimeSummary of all registered pools combined.
+// Pools with statistics collection disabled contribute nothing.
+func AggregateStatsSummary(topN int) RuntimeSummary {
+	return AggregateStatsSummaryBy(topN, SortByReuseHits)
+}
+
+// AggregateStatsSummaryBy is like AggregateStatsSummary, but TopPools is ordered by the given key.
+// Pool statistics are combined by capacity, tag statistics by tag and rates by window.
+// Combined lifetime quantiles are the largest quantile of the combined pools.
+// The pools registered with RegisterStats add their counters, but not their classes.
+func AggregateStatsSummaryBy(topN int, by SortBy) RuntimeSummary {
+	var (
+		sum       RuntimeSummary
+		classes   = make(map[int]*PoolStat)
+		tags      = make(map[string]*TagStat)
+		lifetimes = make(map[int]*LifetimeStat)
+		rates     = make(map[time.Duration]*Rate)
+		windows   []time.Duration
+	)
+	RangeStats(func(_ string, p StatsPool) bool {
+		if !p.GetWithStats() {
+			return true
+		}
+		s := p.StatsSummaryBy(0, by)
+		sum.NewBytes += s.NewBytes
+		sum.NewCount += s.NewCount
+		sum.OutBytes += s.OutBytes
+		sum.OutCount += s.OutCount
+		sum.ReusedBytes += s.ReusedBytes
+		sum.ReusedCount += s.ReusedCount
+		sum.ReleasedCount += s.ReleasedCount
+		sum.DiscardCount += s.DiscardCount
+		sum.Outstanding += s.Outstanding
+		sum.TrimmedBytes += s.TrimmedBytes
+		sum.TrimmedCount += s.TrimmedCount
+		sum.OverflowHits += s.OverflowHits
+		sum.OverflowBytes += s.OverflowBytes
+		sum.OverflowEvict += s.OverflowEvict
+		sum.OverflowCache += s.OverflowCache
+
+		// the capacities of the other pools are not in bytes
+		var stats []PoolStat
+		if cp, ok := p.(*CapacityPools); ok {
+			stats = cp.PoolStats()
+		}
+		for _, st := range stats {
+			c, ok := classes[st.Capacity]
+			if !ok {
+				c = &PoolStat{Capacity: st.Capacity}
+				classes[st.Capacity] = c
+			}
+			c.ReuseHits += st.ReuseHits
+			c.Misses += st.Misses
+			c.Bytes += st.Bytes
+			c.Waste += st.Waste
+			c.Releases += st.Releases
+			c.Outstanding += st.Outstanding
+		}
+		for _, st := range s.Tags {
+			t, ok := tags[st.Tag]
+			if !ok {
+				t = &TagStat{Tag: st.Tag}
+				tags[st.Tag] = t
+			}
+			t.Gets += st.Gets
+			t.Bytes += st.Bytes
+			t.Releases += st.Releases
+			t.Outstanding += st.Outstanding
+			t.OutstandingBytes += st.OutstandingBytes
+		}
+		for _, st := range s.Lifetimes {
+			l, ok := lifetimes[st.Capacity]
+			if !ok {
+				l = &LifetimeStat{Capacity: st.Capacity}
+				lifetimes[st.Capacity] = l
+			}
+			l.Count += st.Count
+			l.P50 = maxDuration(l.P50, st.P50)
+			l.P99 = maxDuration(l.P99, st.P99)
+			l.Max = maxDuration(l.Max, st.Max)
+		}
+		for _, r := range s.Rates {
+			a, ok := rates[r.Window]
+			if !ok {
+				a = &Rate{Window: r.Window}
+				rates[r.Window] = a
+				windows = append(windows, r.Window)
+			}
+			// the reuse ratio is weighted by allocations
+			a.ReuseRatio += r.ReuseRatio * r.AllocsPerSec
+			a.Elapsed = maxDuration(a.Elapsed, r.Elapsed)
+			a.AllocsPerSec += r.AllocsPerSec
+			a.NewPerSec += r.NewPerSec
+			a.OutPerSec += r.OutPerSec
+			a.ReusedPerSec += r.ReusedPerSec
+			a.ReusedBytesPerSec += r.ReusedBytesPerSec
+		}
+		return true
+	})
+
+	if topN > 0 && len(classes) > 0 {
+		arr := make([]PoolStat, 0, len(classes))
+		for _, c := range classes {
+			if gets := c.ReuseHits + c.Misses; gets > 0 {
+				c.ReuseRatio = float64(c.ReuseHits) / float64(gets)
+				c.MissRatio = float64(c.Misses) / float64(gets)
+			}
+			arr = append(arr, *c)
+		}
+		sort.Slice(arr, func(i, j int) bool { return arr[i].Capacity < arr[j].Capacity })
+		sum.TopPools = rankPoolStats(arr, topN, by)
+	}
+	for _, t := range tags {
+		sum.Tags = append(sum.Tags, *t)
+	}
+	sort.Slice(sum.Tags, func(i, j int) bool { return sum.Tags[i].Tag < sum.Tags[j].Tag })
+	for _, l := range lifetimes {
+		sum.Lifetimes = append(sum.Lifetimes, *l)
+	}
+	sort.Slice(sum.Lifetimes, func(i, j int) bool { return sum.Lifetimes[i].Capacity < sum.Lifetimes[j].Capacity })
+	for _, w := range windows {
+		a := rates[w]
+		if a.AllocsPerSec > 0 {
+			a.ReuseRatio /= a.AllocsPerSec
+		} else {
+			a.ReuseRatio = 0
+		}
+		sum.Rates = append(sum.Rates, *a)
+	}
+	return sum
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,168 @@
+package bytespool
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRegistry(t *testing.T) {
+	if Lookup(DefaultPoolName) != Default() {
+		t.Fatal("expect the default pools are registered")
+	}
+	p := NewCapacityPools(8, 64)
+	if err := Register("", p); err != ErrEmptyName {
+		t.Fatalf("expect ErrEmptyName, but got %v", err)
+	}
+	if err := Register("registry-test", p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer Unregister("registry-test")
+	if err := Register("registry-test", p); err != ErrDuplicateName {
+		t.Fatalf("expect ErrDuplicateName, but got %v", err)
+	}
+	if err := Register("registry-test-alias", p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if Lookup("registry-test") != p || Lookup("none") != nil {
+		t.Fatal("unexpected lookup result")
+	}
+
+	// the alias is the same pool
+	var names []string
+	for _, name := range PoolNames() {
+		if name == "registry-test" || name == "registry-test-alias" {
+			names = append(names, name)
+		}
+	}
+	if len(names) != 1 || names[0] != "registry-test" {
+		t.Fatalf("expect only the first name, but got %v", names)
+	}
+
+	n := 0
+	RangePools(func(name string, _ *CapacityPools) bool {
+		n++
+		return false
+	})
+	if n != 1 {
+		t.Fatalf("expect the iteration to stop, but got %d calls", n)
+	}
+
+	Unregister("registry-test-alias")
+	Unregister("registry-test-alias")
+	if Lookup("registry-test-alias") != nil {
+		t.Fatal("expect the name is unregistered")
+	}
+}
+
+func TestPoolSet(t *testing.T) {
+	p := NewCapacityPools(8, 64)
+	q := NewCapacityPools(8, 128)
+	names := func(s *PoolSet) []string {
+		var names []string
+		s.Range(func(name string, _ *CapacityPools) bool {
+			names = append(names, name)
+			return true
+		})
+		return names
+	}
+
+	s := NewPoolSet(false)
+	s.Add("p", p)
+	s.Add("alias", p)
+	s.AddFunc("q", func() *CapacityPools { return q })
+	s.AddFunc("nil", func() *CapacityPools { return nil })
+	if got := names(s); len(got) != 2 || got[0] != "p" || got[1] != "q" {
+		t.Fatalf("expect p and q, but got %v", got)
+	}
+	s.Add("p", q)
+	s.Remove("q")
+	s.Remove("none")
+	if got := names(s); len(got) != 2 || got[0] != "p" || got[1] != "alias" {
+		t.Fatalf("expect p replaced and q removed, but got %v", got)
+	}
+
+	// the registered pools come first, resolved at every Range
+	s = NewPoolSet(true)
+	s.Add("default-alias", Default())
+	_ = Register("pool-set-test", p)
+	got := names(s)
+	Unregister("pool-set-test")
+	if len(got) < 2 || got[0] != DefaultPoolName || got[len(got)-1] != "pool-set-test" {
+		t.Fatalf("expect the registered pools, but got %v", got)
+	}
+	if got = names(s); got[len(got)-1] == "pool-set-test" {
+		t.Fatalf("expect the unregistered pools are gone, but got %v", got)
+	}
+}
+
+func TestAggregateStatsSummary(t *testing.T) {
+	skipNoPool(t)
+	a := NewCapacityPools(8, 64)
+	a.SetWithStats(true)
+	a.SetWithLifetime(true)
+	b := NewCapacityPools(16, 128)
+	b.SetWithStats(true)
+	_ = Register("aggregate-a", a)
+	_ = Register("aggregate-b", b)
+	defer Unregister("aggregate-a")
+	defer Unregister("aggregate-b")
+
+	a.Release(a.New(20))
+	_ = a.New(20)
+	_ = a.New(100)
+	_ = b.New(20)
+	_ = b.New(1000)
+	_ = a.Tagged("x").New(8)
+	_ = b.Tagged("x").New(8)
+	a.ObserveLifetime(32, a.LifetimeStamp())
+	a.StartSampler(time.Second, time.Minute)
+	b.StartSampler(time.Second, time.Minute)
+	defer a.StopSampler()
+	defer b.StopSampler()
+
+	// other registered pools (default, buffer) may have statistics enabled by other tests
+	base := RuntimeSummary{}
+	RangePools(func(name string, p *CapacityPools) bool {
+		if p != a && p != b {
+			s := RuntimeStatsSummary(0, p)
+			base.NewCount += s.NewCount + s.ReusedCount
+			base.OutBytes += s.OutBytes
+		}
+		return true
+	})
+
+	sum := AggregateStatsSummary(10)
+	// the reuse is not exact under the race detector, so count all the gets
+	if sum.NewCount+sum.ReusedCount-base.NewCount != 5 || sum.OutBytes-base.OutBytes != 1100 {
+		t.Fatalf("unexpected aggregate: %+v", sum)
+	}
+	var class32 *PoolStat
+	top := AggregateStatsSummaryBy(10, SortByBytes).TopPools
+	for i := range top {
+		if top[i].Capacity == 32 {
+			class32 = &top[i]
+		}
+	}
+	if class32 == nil || class32.Misses+class32.ReuseHits < 3 || !raceEnabled && class32.ReuseHits < 1 {
+		t.Fatalf("expect class 32 is combined, but got %+v", class32)
+	}
+	var tx *TagStat
+	for i := range sum.Tags {
+		if sum.Tags[i].Tag == "x" {
+			tx = &sum.Tags[i]
+		}
+	}
+	if tx == nil || tx.Gets != 2 || tx.Bytes != 24 {
+		t.Fatalf("expect tag x is combined, but got %+v", tx)
+	}
+	if len(sum.Lifetimes) == 0 || len(sum.Rates) == 0 || sum.Rates[0].Window != time.Minute {
+		t.Fatalf("unexpected lifetimes or rates: %+v, %+v", sum.Lifetimes, sum.Rates)
+	}
+}
+
+func TestBufPool_Pools(t *testing.T) {
+	bp := NewBufPool(1024)
+	if bp.Pools().MinSize() != 1024 || bp.Pools().MaxSize() != 1024 {
+		t.Fatal("unexpected pools of BufPool")
+	}
+}
@@ -0,0 +1,261 @@
+package bytespool
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	defaultSampleInterval = time.Second
+	minSampleInterval     = 10 * time.Millisecond
+)
+
+// DefaultRateWindows are the windows reported by Rates when none are given to StartSampler.
+var DefaultRateWindows = []time.Duration{10 * time.Second, time.Minute, 5 * time.Minute}
+
+// Snapshot is a point-in-time copy of the pool counters.
+type Snapshot struct {
+	Time        time.Time
+	NewCount    uint64 // number of byte slices newly allocated for pools
+	NewBytes    uint64 // bytes newly allocated for pools
+	OutCount    uint64 // number of byte slices allocated outside pools
+	OutBytes    uint64 // bytes allocated outside pools
+	ReusedCount uint64 // number of byte slices reused from pools
+	ReusedBytes uint64 // bytes reused from pools
+}
+
+// Allocs returns the total number of byte slices handed out.
+func (s Snapshot) Allocs() uint64 {
+	return s.NewCount + s.OutCount + s.ReusedCount
+}
+
+// Rate describes the pool activity over a time window.
+type Rate struct {
+	Window            time.Duration `json:"Window"`            // requested window
+	Elapsed           time.Duration `json:"Elapsed"`           // time actually covered by the samples, may be shorter than Window
+	AllocsPerSec      float64       `json:"AllocsPerSec"`      // byte slices handed out per second
+	NewPerSec         float64       `json:"NewPerSec"`         // byte slices newly allocated for pools per second
+	OutPerSec         float64       `json:"OutPerSec"`         // byte slices allocated outside pools per second
+	ReusedPerSec      float64       `json:"ReusedPerSec"`      // byte slices reused from pools per second
+	ReusedBytesPerSec float64       `json:"ReusedBytesPerSec"` // bytes reused from pools per second
+	ReuseRatio        float64       `json:"ReuseRatio"`        // reused / allocs within the window, [0,1]
+}
+
+// sampler keeps a ring of periodic snapshots of a CapacityPools.
+type sampler struct {
+	p        *CapacityPools
+	interval time.Duration
+	windows  []time.Duration
+	now      func() time.Time
+
+	mu   sync.Mutex
+	ring []Snapshot
+	next int
+	size int
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newSampler(p *CapacityPools, interval time.Duration, windows []time.Duration) *sampler {
+	if interval <= 0 {
+		interval = defaultSampleInterval
+	}
+	if interval < minSampleInterval {
+		interval = minSampleInterval
+	}
+	if len(windows) == 0 {
+		windows = DefaultRateWindows
+	}
+	ws := make([]time.Duration, 0, len(windows))
+	var longest time.Duration
+	for _, w := range windows {
+		if w <= 0 {
+			continue
+		}
+		ws = append(ws, w)
+		if w > longest {
+			longest = w
+		}
+	}
+	n := int(longest/interval) + 2
+	if n < 2 {
+		n = 2
+	}
+	return &sampler{
+		p:        p,
+		interval: interval,
+		windows:  ws,
+		now:      time.Now,
+		ring:     make([]Snapshot, n),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+func (s *sampler) run() {
+	defer close(s.done)
+	t := time.NewTicker(s.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-t.C:
+			s.sample()
+		}
+	}
+}
+
+func (s *sampler) close() {
+	close(s.stop)
+	<-s.done
+}
+
+// sample appends the current counters to the ring.
+func (s *sampler) sample() {
+	snap := s.p.snapshot(s.now())
+	s.mu.Lock()
+	s.ring[s.next] = snap
+	s.next = (s.next + 1) % len(s.ring)
+	if s.size < len(s.ring) {
+		s.size++
+	}
+	s.mu.Unlock()
+}
+
+// rates computes the rates for every configured window, ending at the current counters.
+func (s *sampler) rates() []Rate {
+	cur := s.p.snapshot(s.now())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rates := make([]Rate, 0, len(s.windows))
+	for _, w := range s.windows {
+		from, ok := s.oldestWithin(cur.Time.Add(-w))
+		rates = append(rates, computeRate(w, from, ok, cur))
+	}
+	return rates
+}
+
+// oldestWithin returns the oldest snapshot taken at or after since.
+// If no sample is that recent, the newest one is used.
+func (s *sampler) oldestWithin(since time.Time) (Snapshot, bool) {
+	if s.size == 0 {
+		return Snapshot{}, false
+	}
+	n := len(s.ring)
+	oldest := (s.next - s.size + n) % n
+	for i := 0; i < s.size; i++ {
+		snap := s.ring[(oldest+i)%n]
+		if !snap.Time.Before(since) {
+			return snap, true
+		}
+	}
+	return s.ring[(s.next-1+n)%n], true
+}
+
+func computeRate(w time.Duration, from Snapshot, ok bool, to Snapshot) Rate {
+	r := Rate{Window: w}
+	if !ok {
+		return r
+	}
+	r.Elapsed = to.Time.Sub(from.Time)
+	allocs := float64(to.Allocs() - from.Allocs())
+	reused := float64(to.ReusedCount - from.ReusedCount)
+	if allocs > 0 {
+		r.ReuseRatio = reused / allocs
+	}
+	sec := r.Elapsed.Seconds()
+	if sec <= 0 {
+		return r
+	}
+	r.AllocsPerSec = allocs / sec
+	r.NewPerSec = float64(to.NewCount-from.NewCount) / sec
+	r.OutPerSec = float64(to.OutCount-from.OutCount) / sec
+	r.ReusedPerSec = reused / sec
+	r.ReusedBytesPerSec = float64(to.ReusedBytes-from.ReusedBytes) / sec
+	return r
+}
+
+// snapshot returns the current counters stamped with t.
+func (p *CapacityPools) snapshot(t time.Time) Snapshot {
+	return Snapshot{
+		Time:        t,
+		NewCount:    p.getNewCount(),
+		NewBytes:    p.getTotalNewBytes(),
+		OutCount:    p.getOutCount(),
+		OutBytes:    p.getTotalOutBytes(),
+		ReusedCount: p.getReusedCount(),
+		ReusedBytes: p.getTotalReusedBytes(),
+	}
+}
+
+// Snapshot returns a point-in-time copy of the pool counters.
+// All counters are zero unless statistics collection is enabled.
+func (p *CapacityPools) Snapshot() Snapshot {
+	return p.snapshot(time.Now())
+}
+
+// StartSampler starts a background goroutine that snapshots the pool counters every interval,
+// keeping enough history to report rates over the longest of the windows.
+// If windows is empty, DefaultRateWindows is used. A running sampler is replaced.
+// Statistics collection must be enabled with SetWithStats, otherwise all rates are zero.
+func (p *CapacityPools) StartSampler(interval time.Duration, windows ...time.Duration) {
+	s := newSampler(p, interval, windows)
+	s.sample()
+
+	p.mu.Lock()
+	old := p.sampler
+	p.sampler = s
+	p.mu.Unlock()
+
+	if old != nil {
+		old.close()
+	}
+	go s.run()
+}
+
+// StopSampler stops the background sampler, if any, and discards its history.
+func (p *CapacityPools) StopSampler() {
+	p.mu.Lock()
+	s := p.sampler
+	p.sampler = nil
+	p.mu.Unlock()
+
+	if s != nil {
+		s.close()
+	}
+}
+
+// Rates returns the pool activity over each sampler window.
+// It returns nil if the sampler is not running.
+func (p *CapacityPools) Rates() []Rate {
+	p.mu.Lock()
+	s := p.sampler
+	p.mu.Unlock()
+
+	if s == nil {
+		return nil
+	}
+	return s.rates()
+}
+
+// StartSampler starts the rate sampler on the default pools.
+func StartSampler(interval time.Duration, windows ...time.Duration) {
+	Default().StartSampler(interval, windows...)
+}
+
+// StopSampler stops the rate sampler on the default pools.
+func StopSampler() {
+	Default().StopSampler()
+}
+
+// Rates returns the windowed rates of the provided CapacityPools (or the default pools when none provided).
+// It returns nil if the sampler is not running.
+func Rates(ps ...*CapacityPools) []Rate {
+	p := Default()
+	if len(ps) > 0 {
+		p = ps[0]
+	}
+	return p.Rates()
+}
@@ -0,0 +1,109 @@
+package bytespool
+
+import (
+	"math"
+	"runtime/debug"
+	"testing"
+	"time"
+)
+
+func TestSampler_Rates(t *testing.T) {
+	skipNoPool(t)
+	p := NewCapacityPools(2, 128)
+	p.SetWithStats(true)
+	gc := debug.SetGCPercent(-1)
+	defer debug.SetGCPercent(gc)
+
+	now := time.Unix(1000, 0)
+	s := newSampler(p, time.Second, []time.Duration{10 * time.Second, time.Minute})
+	s.now = func() time.Time { return now }
+	if len(s.ring) != 62 {
+		t.Fatalf("expect ring size is 62, but got %d", len(s.ring))
+	}
+	s.sample()
+
+	// 1 new, 9 reused, 10 out of range in 10 seconds
+	for i := 0; i < 10; i++ {
+		buf := p.New(8)
+		p.Release(buf)
+		_ = p.New(200)
+	}
+	now = now.Add(10 * time.Second)
+
+	rates := s.rates()
+	if len(rates) != 2 {
+		t.Fatalf("expect 2 rates, but got %d", len(rates))
+	}
+	r := rates[0]
+	if r.Window != 10*time.Second || r.Elapsed != 10*time.Second {
+		t.Fatalf("expect window and elapsed are 10s, but got %s, %s", r.Window, r.Elapsed)
+	}
+	if r.AllocsPerSec != 2 {
+		t.Fatalf("expect allocs/s is 2, but got %v", r.AllocsPerSec)
+	}
+	if r.OutPerSec != 1 {
+		t.Fatalf("expect out/s is 1, but got %v", r.OutPerSec)
+	}
+	if math.Abs(r.ReusedPerSec+r.NewPerSec-1) > 1e-9 {
+		t.Fatalf("expect reused/s + new/s is 1, but got %v, %v", r.ReusedPerSec, r.NewPerSec)
+	}
+	if !raceEnabled {
+		if r.ReusedPerSec != 0.9 || r.NewPerSec != 0.1 {
+			t.Fatalf("expect reused/s is 0.9, new/s is 0.1, but got %v, %v", r.ReusedPerSec, r.NewPerSec)
+		}
+		if math.Abs(r.ReuseRatio-0.45) > 1e-9 {
+			t.Fatalf("expect reuse ratio is 0.45, but got %v", r.ReuseRatio)
+		}
+	}
+
+	// Only the last 10 seconds are counted by the short window.
+	s.sample()
+	now = now.Add(10 * time.Second)
+	_ = p.New(200)
+	rates = s.rates()
+	if rates[0].AllocsPerSec != 0.1 || rates[0].ReuseRatio != 0 {
+		t.Fatalf("expect allocs/s is 0.1 and no reuse, but got %v, %v", rates[0].AllocsPerSec, rates[0].ReuseRatio)
+	}
+	if rates[1].Elapsed != 20*time.Second || rates[1].AllocsPerSec != 21.0/20 {
+		t.Fatalf("expect 21 allocs in 20s, but got %v in %s", rates[1].AllocsPerSec, rates[1].Elapsed)
+	}
+}
+
+func TestSampler_Ring(t *testing.T) {
+	p := NewCapacityPools(2, 128)
+	now := time.Unix(0, 0)
+	s := newSampler(p, time.Second, []time.Duration{2 * time.Second})
+	s.now = func() time.Time { return now }
+	for i := 0; i < 10; i++ {
+		s.sample()
+		now = now.Add(time.Second)
+	}
+	if s.size != len(s.ring) {
+		t.Fatalf("expect ring is full, but got %d/%d", s.size, len(s.ring))
+	}
+	from, ok := s.oldestWithin(now.Add(-2 * time.Second))
+	if !ok || !from.Time.Equal(now.Add(-2*time.Second)) {
+		t.Fatalf("unexpected oldest snapshot: %v", from.Time)
+	}
+}
+
+func TestStartSampler(t *testing.T) {
+	p := NewCapacityPools(2, 128)
+	p.SetWithStats(true)
+	if p.Rates() != nil {
+		t.Fatal("expect no rates before the sampler starts")
+	}
+	p.StartSampler(10*time.Millisecond, time.Second)
+	p.StartSampler(10*time.Millisecond, time.Second, time.Minute)
+	if n := len(Rates(p)); n != 2 {
+		t.Fatalf("expect 2 rates, but got %d", n)
+	}
+	if n := len(RuntimeStatsSummary(1, p).Rates); n != 2 {
+		t.Fatalf("expect 2 rates in summary, but got %d", n)
+	}
+	p.StopSampler()
+	p.StopSampler()
+	if p.Rates() != nil {
+		t.Fatal("expect no rates after the sampler stops")
+	}
+}
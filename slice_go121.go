@@ -0,0 +1,22 @@
+//go:build go1.21 && !purego
+// +build go1.21,!purego
+
+package bytespool
+
+import (
+	"unsafe"
+)
+
+// item is what the class caches hold: the array pointer of a byte slice,
+// which is stored in sync.Pool without allocation.
+type item = *byte
+
+// toItem returns the array pointer of buf.
+func toItem(buf []byte) item {
+	return unsafe.SliceData(buf)
+}
+
+// fromItem returns the byte slice of the array pointer it.
+func fromItem(it item, len, cap int) []byte {
+	return unsafe.Slice(it, cap)[:len]
+}
@@ -0,0 +1,32 @@
+//go:build !go1.21 && !purego
+// +build !go1.21,!purego
+
+package bytespool
+
+import (
+	"unsafe"
+)
+
+// item is what the class caches hold: the array pointer of a byte slice,
+// which is stored in sync.Pool without allocation.
+type item = *byte
+
+type bytesHeader struct {
+	Data *byte
+	Len  int
+	Cap  int
+}
+
+// toItem returns the array pointer of buf.
+func toItem(buf []byte) item {
+	return (*bytesHeader)(unsafe.Pointer(&buf)).Data
+}
+
+// fromItem returns the byte slice of the array pointer it.
+func fromItem(it item, len, cap int) (buf []byte) {
+	sh := (*bytesHeader)(unsafe.Pointer(&buf))
+	sh.Data = it
+	sh.Len = len
+	sh.Cap = cap
+	return
+}
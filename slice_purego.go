@@ -0,0 +1,19 @@
+//go:build purego
+// +build purego
+
+package bytespool
+
+// item is what the class caches hold: a boxed byte slice,
+// which costs an allocation per release but needs no unsafe.
+type item = *[]byte
+
+// toItem returns the boxed buf.
+func toItem(buf []byte) item {
+	buf = buf[:cap(buf)]
+	return &buf
+}
+
+// fromItem returns the byte slice boxed in it.
+func fromItem(it item, len, cap int) []byte {
+	return (*it)[:len:cap]
+}
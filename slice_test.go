@@ -0,0 +1,30 @@
+package bytespool
+
+import (
+	"testing"
+)
+
+func TestItem(t *testing.T) {
+	buf := make([]byte, 3, 8)
+	copy(buf, "abc")
+	got := fromItem(toItem(buf), 5, 8)
+	if len(got) != 5 || cap(got) != 8 || &got[0] != &buf[0] || string(got[:3]) != "abc" {
+		t.Fatalf("expect the same array, but got len=%d, cap=%d, %q", len(got), cap(got), got)
+	}
+	if got = fromItem(toItem(buf[:0]), 0, 8); cap(got) != 8 || &got[:1][0] != &buf[0] {
+		t.Fatal("expect the same array of an empty byte slice")
+	}
+}
+
+func TestBytes(t *testing.T) {
+	buf := Bytes(3, 8)
+	if len(buf) != 3 || cap(buf) != 8 {
+		t.Fatalf("expect len=3, cap=8, but got len=%d, cap=%d", len(buf), cap(buf))
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expect panic when len is greater than cap")
+		}
+	}()
+	_ = Bytes(8, 3)
+}
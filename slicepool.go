@@ -0,0 +1,289 @@
+//go:build go1.21
+// +build go1.21
+
+package bytespool
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// SlicePool is CapacityPools for slices of any element type, e.g. []int64, []string or []*Item:
+// it divides into multiple pools according to the capacity scale, power-of-two classes
+// (NewSlicePool) or custom ones (NewSlicePoolClasses), with the same statistics.
+// SlicePool[byte] behaves like CapacityPools, which is kept as the []byte pool for the builds before Go 1.21.
+// It needs Go 1.21, the first version honoring the language version of a build-constrained file
+// while go.mod declares Go 1.14.
+//
+// Unlike CapacityPools, the elements are zeroed on Release, so that the pool does not retain what they reference,
+// and the slices handed out never contain old data.
+type SlicePool[T any] struct {
+	pools     []*slicePool[T]
+	classes   []int // Capacities of the pools, ascending
+	pow2      bool  // The classes are the consecutive powers of two from classes[0]
+	decIndex  int
+	elemSize  uint64 // Size of T, the statistics are in bytes like CapacityPools
+	newCount  uint64 // Number of slices newly allocated for pools
+	newBytes  uint64 // New bytes allocated for pools
+	outCount  uint64 // Number of slices allocated outside pools
+	outBytes  uint64 // Bytes allocated outside pools
+	discards  uint64 // Number of slices discarded by Release
+	withStats bool   // Controls whether to collect statistics for this pool
+}
+
+var _ StatsPool = (*SlicePool[byte])(nil)
+
+// slicePool represents a pool for a specific capacity
+type slicePool[T any] struct {
+	pool     sync.Pool
+	capacity int
+	classCounters
+}
+
+// NewSlicePool divide into multiple pools according to the capacity scale, like NewCapacityPools.
+// Maximum range of slice pool: [minCapacity,math.MaxInt32]
+func NewSlicePool[T any](minSize, maxSize int) *SlicePool[T] {
+	minSize, maxSize = clampSizes(minSize, maxSize)
+	var classes []int
+	mn := getIndex(minSize)
+	mx := getIndex(maxSize)
+	for i := mn; i <= mx; i++ {
+		classes = append(classes, 1<<i)
+	}
+	p := newSlicePool[T](classes)
+	p.pow2 = true
+	p.decIndex = mn
+	return p
+}
+
+// NewSlicePoolClasses returns a SlicePool with the given class capacities, e.g. the sizes used the most.
+// The capacities are sorted, duplicates and those out of [minCapacity,math.MaxInt32] are ignored.
+// It panics if no capacity is left.
+func NewSlicePoolClasses[T any](capacities ...int) *SlicePool[T] {
+	var classes []int
+	for _, c := range capacities {
+		if c >= minCapacity && c <= math.MaxInt32 {
+			classes = append(classes, c)
+		}
+	}
+	if len(classes) == 0 {
+		panic("bytespool: no valid class capacity")
+	}
+	sort.Ints(classes)
+	n := 1
+	for _, c := range classes[1:] {
+		if c != classes[n-1] {
+			classes[n] = c
+			n++
+		}
+	}
+	return newSlicePool[T](classes[:n])
+}
+
+func newSlicePool[T any](classes []int) *SlicePool[T] {
+	p := &SlicePool[T]{
+		classes:  classes,
+		elemSize: uint64(reflect.TypeOf((*T)(nil)).Elem().Size()),
+	}
+	for _, c := range classes {
+		p.pools = append(p.pools, &slicePool[T]{capacity: c})
+	}
+	return p
+}
+
+// SetWithStats enables or disables statistics collection for this pool.
+// This function is not thread-safe and should be called before any pool operations.
+func (p *SlicePool[T]) SetWithStats(t bool) {
+	p.withStats = t
+}
+
+// GetWithStats returns the current status of statistics collection for this pool.
+func (p *SlicePool[T]) GetWithStats() bool {
+	return p.withStats
+}
+
+// MinSize returns the smallest class capacity.
+func (p *SlicePool[T]) MinSize() int {
+	return p.classes[0]
+}
+
+// MaxSize returns the largest class capacity.
+func (p *SlicePool[T]) MaxSize() int {
+	return p.classes[len(p.classes)-1]
+}
+
+// Classes returns the class capacities in ascending order.
+func (p *SlicePool[T]) Classes() []int {
+	return append([]int(nil), p.classes...)
+}
+
+// New return a slice of the specified size, its elements are zero.
+// Warning: returned slice is never equal to nil
+func (p *SlicePool[T]) New(size int) []T {
+	if size < 0 {
+		size = 0
+	}
+
+	sp := p.getMakePool(size)
+	if sp == nil {
+		if p.withStats {
+			atomic.AddUint64(&p.outCount, 1)
+			atomic.AddUint64(&p.outBytes, uint64(size)*p.elemSize)
+		}
+		return make([]T, size)
+	}
+
+	if p.withStats {
+		atomic.AddUint64(&sp.reqSize, uint64(size))
+	}
+
+	var s []T
+	if !NoPool {
+		s = getSlice[T](&sp.pool, size, sp.capacity)
+	}
+	if s == nil {
+		if p.withStats {
+			atomic.AddUint64(&sp.misses, 1)
+			atomic.AddUint64(&p.newCount, 1)
+			atomic.AddUint64(&p.newBytes, uint64(sp.capacity)*p.elemSize)
+		}
+		return make([]T, size, sp.capacity)
+	}
+
+	if p.withStats {
+		atomic.AddUint64(&sp.reuseHits, 1)
+	}
+	return s
+}
+
+// Make return a slice of length 0.
+func (p *SlicePool[T]) Make(capacity int) []T {
+	return p.New(capacity)[:0]
+}
+
+// Clone return a copy of the slice.
+func (p *SlicePool[T]) Clone(s []T) []T {
+	return append(p.Make(len(s)), s...)
+}
+
+// Append similar to the built-in function to append elements to the end of a slice.
+// If there is insufficient capacity,
+// a new underlying array is allocated and the old array is reclaimed.
+func (p *SlicePool[T]) Append(s []T, elems ...T) []T {
+	n := len(s)
+	m := n + len(elems)
+	if cap(s) < m && cap(s) <= p.MaxSize() {
+		ns := p.New(m)
+		copy(ns, s)
+		copy(ns[n:], elems)
+		p.Release(s)
+		return ns
+	}
+	return append(s, elems...)
+}
+
+// Release zeroes the elements and puts the slice back into the pool of the corresponding scale.
+// Slices smaller than the minimum capacity or larger than the maximum capacity are discarded.
+func (p *SlicePool[T]) Release(s []T) bool {
+	sp := p.getReleasePool(cap(s))
+	if sp == nil {
+		if p.withStats {
+			atomic.AddUint64(&p.discards, 1)
+		}
+		return false
+	}
+
+	if p.withStats {
+		atomic.AddUint64(&sp.releases, 1)
+	}
+	if NoPool {
+		return true
+	}
+
+	var zero T
+	s = s[:cap(s)]
+	for i := range s {
+		s[i] = zero
+	}
+	putSlice(&sp.pool, s)
+	return true
+}
+
+// Put is the same as Release.
+func (p *SlicePool[T]) Put(s []T) {
+	p.Release(s)
+}
+
+func (p *SlicePool[T]) getMakePool(size int) *slicePool[T] {
+	if size <= p.classes[0] {
+		return p.pools[0]
+	}
+	if size > p.MaxSize() {
+		return nil
+	}
+	if p.pow2 {
+		return p.pools[getIndex(size)-p.decIndex]
+	}
+	return p.pools[sort.SearchInts(p.classes, size)]
+}
+
+// getReleasePool returns the largest pool of a capacity not above size, nil if none.
+func (p *SlicePool[T]) getReleasePool(size int) *slicePool[T] {
+	if size < p.classes[0] || size > p.MaxSize() {
+		return nil
+	}
+	i := sort.SearchInts(p.classes, size)
+	if p.classes[i] != size {
+		i--
+	}
+	return p.pools[i]
+}
+
+// StatsSummary returns the RuntimeSummary of the pool, like RuntimeStatsSummary.
+// The capacities are in elements, the bytes are the elements times the size of T.
+func (p *SlicePool[T]) StatsSummary(topN int) RuntimeSummary {
+	return p.StatsSummaryBy(topN, SortByReuseHits)
+}
+
+// StatsSummaryBy is like StatsSummary, but TopPools is ordered by the given key.
+func (p *SlicePool[T]) StatsSummaryBy(topN int, by SortBy) RuntimeSummary {
+	if !p.withStats {
+		return RuntimeSummary{}
+	}
+
+	sum := RuntimeSummary{
+		NewBytes:     atomic.LoadUint64(&p.newBytes),
+		NewCount:     atomic.LoadUint64(&p.newCount),
+		OutBytes:     atomic.LoadUint64(&p.outBytes),
+		OutCount:     atomic.LoadUint64(&p.outCount),
+		DiscardCount: atomic.LoadUint64(&p.discards),
+	}
+	stats := p.PoolStats()
+	for _, st := range stats {
+		sum.ReusedCount += st.ReuseHits
+		sum.ReusedBytes += st.ReuseHits * uint64(st.Capacity) * p.elemSize
+		sum.ReleasedCount += st.Releases
+	}
+	sum.Outstanding = outstanding(sum.NewCount+sum.ReusedCount, sum.ReleasedCount)
+	if topN > 0 {
+		sum.TopPools = rankPoolStats(stats, topN, by)
+	}
+	return sum
+}
+
+// PoolStats returns the statistics of every pool in ascending capacity order, Rank is not set.
+// It returns nil if statistics collection is disabled.
+func (p *SlicePool[T]) PoolStats() []PoolStat {
+	if !p.withStats {
+		return nil
+	}
+
+	stats := make([]PoolStat, 0, len(p.pools))
+	for _, sp := range p.pools {
+		stats = append(stats, sp.stat(sp.capacity, p.elemSize))
+	}
+	return stats
+}
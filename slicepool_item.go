@@ -0,0 +1,24 @@
+//go:build go1.21 && !purego
+// +build go1.21,!purego
+
+package bytespool
+
+import (
+	"sync"
+	"unsafe"
+)
+
+// putSlice caches the array pointer of s, which is stored in sync.Pool without allocation.
+// The capacity of the pooled slices is never 0.
+func putSlice[T any](pool *sync.Pool, s []T) {
+	pool.Put(&s[:1][0])
+}
+
+// getSlice returns a cached slice of the class capacity, nil if none.
+func getSlice[T any](pool *sync.Pool, len, cap int) []T {
+	e, _ := pool.Get().(*T)
+	if e == nil {
+		return nil
+	}
+	return unsafe.Slice(e, cap)[:len]
+}
@@ -0,0 +1,23 @@
+//go:build go1.21 && purego
+// +build go1.21,purego
+
+package bytespool
+
+import (
+	"sync"
+)
+
+// putSlice caches the boxed s, which costs an allocation per release but needs no unsafe.
+func putSlice[T any](pool *sync.Pool, s []T) {
+	s = s[:cap(s)]
+	pool.Put(&s)
+}
+
+// getSlice returns a cached slice of the class capacity, nil if none.
+func getSlice[T any](pool *sync.Pool, len, cap int) []T {
+	e, _ := pool.Get().(*[]T)
+	if e == nil {
+		return nil
+	}
+	return (*e)[:len:cap]
+}
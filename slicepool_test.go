@@ -0,0 +1,149 @@
+//go:build go1.21
+// +build go1.21
+
+package bytespool
+
+import (
+	"testing"
+)
+
+type testItem struct {
+	name string
+}
+
+func TestSlicePool(t *testing.T) {
+	p := NewSlicePool[int64](4, 64)
+	p.SetWithStats(true)
+	if p.MinSize() != 4 || p.MaxSize() != 64 || !p.GetWithStats() {
+		t.Fatalf("unexpected pool settings: %v", p.Classes())
+	}
+
+	s := p.New(10)
+	if len(s) != 10 || cap(s) != 16 {
+		t.Fatalf("expect len 10 and cap 16, but got %d, %d", len(s), cap(s))
+	}
+	s[0] = 42
+	s = p.Append(s, 1, 2, 3, 4, 5, 6, 7)
+	if len(s) != 17 || cap(s) != 32 || s[0] != 42 {
+		t.Fatalf("expect len 17 and cap 32, but got %d, %d", len(s), cap(s))
+	}
+	if !p.Release(s) {
+		t.Fatal("expect to release the slice successfully, but not")
+	}
+	if p.Release(make([]int64, 3)) || p.Release(make([]int64, 100)) {
+		t.Fatal("expect the out of range slices are discarded")
+	}
+	if s = p.New(100); len(s) != 100 {
+		t.Fatal("expect a slice outside the pools")
+	}
+
+	c := p.Clone([]int64{1, 2, 3})
+	if len(c) != 3 || cap(c) != 4 || c[2] != 3 {
+		t.Fatalf("unexpected clone: %v", c)
+	}
+	p.Put(c)
+	if s = p.Make(3); len(s) != 0 || cap(s) != 4 {
+		t.Fatal("unexpected slice")
+	}
+
+	sum := p.StatsSummary(3)
+	if sum.NewCount+sum.ReusedCount != 4 || sum.ReleasedCount != 3 || sum.Outstanding != 1 ||
+		sum.OutCount != 1 || sum.OutBytes != 800 || sum.DiscardCount != 2 {
+		t.Fatalf("unexpected stats: %+v", sum)
+	}
+	// 16 + 32 + 4 + 4 elements of 8 bytes
+	if n := sum.NewBytes + sum.ReusedBytes; n != 448 {
+		t.Fatalf("expect the bytes in elements of 8 bytes, but got %d", n)
+	}
+	if st := p.PoolStats(); len(st) != 5 || st[2].Capacity != 16 || st[2].Misses != 1 || st[2].Bytes != 128 {
+		t.Fatalf("unexpected pool stats: %+v", st)
+	}
+}
+
+func TestSlicePool_Reuse(t *testing.T) {
+	skipNoPool(t)
+	p := NewSlicePool[*testItem](2, 16)
+	s := p.Make(8)
+	s = append(s, &testItem{"a"}, &testItem{"b"})
+	first := &s[:1][0]
+	p.Release(s)
+	s = p.New(8)
+	if &s[0] != first {
+		t.Skip("the slice was dropped by sync.Pool")
+	}
+	for i := range s {
+		if s[i] != nil {
+			t.Fatal("expect the elements are zeroed on release")
+		}
+	}
+}
+
+func TestSlicePoolClasses(t *testing.T) {
+	p := NewSlicePoolClasses[string](100, 10, 1, 1000, 10)
+	if c := p.Classes(); len(c) != 3 || c[0] != 10 || c[2] != 1000 {
+		t.Fatalf("expect classes 10, 100, 1000, but got %v", c)
+	}
+	for _, tt := range []struct{ size, cap int }{{0, 10}, {10, 10}, {11, 100}, {999, 1000}, {1001, 1001}} {
+		if s := p.New(tt.size); len(s) != tt.size || cap(s) != tt.cap {
+			t.Fatalf("expect cap %d for size %d, but got %d", tt.cap, tt.size, cap(s))
+		}
+	}
+	// released to the largest class it holds
+	if !p.Release(make([]string, 0, 500)) || p.Release(make([]string, 0, 5)) {
+		t.Fatal("unexpected release")
+	}
+	if s := p.Make(50); cap(s) != 100 {
+		t.Fatalf("expect cap 100, but got %d", cap(s))
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expect panic without valid classes")
+		}
+	}()
+	NewSlicePoolClasses[int](0, 1)
+}
+
+func TestSlicePool_RegisterStats(t *testing.T) {
+	p := NewSlicePool[int64](4, 64)
+	p.SetWithStats(true)
+	base := AggregateStatsSummary(0)
+	if err := RegisterStats("slice-test", p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer Unregister("slice-test")
+	if err := RegisterStats("slice-test", p); err != ErrDuplicateName {
+		t.Fatalf("expect ErrDuplicateName, but got %v", err)
+	}
+
+	_ = p.New(10)
+	_ = p.New(100)
+	sum := AggregateStatsSummary(0)
+	if sum.NewCount-base.NewCount != 1 || sum.NewBytes-base.NewBytes != 16*8 || sum.OutBytes-base.OutBytes != 800 {
+		t.Fatalf("expect the slice pool is combined, but got %+v", sum)
+	}
+
+	// only visited by RangeStats
+	var stats, pools bool
+	RangeStats(func(name string, s StatsPool) bool {
+		stats = stats || name == "slice-test" && s == StatsPool(p)
+		return true
+	})
+	RangePools(func(name string, _ *CapacityPools) bool {
+		pools = pools || name == "slice-test"
+		return true
+	})
+	if !stats || pools || Lookup("slice-test") != nil {
+		t.Fatalf("expect the slice pool is only visited by RangeStats, but got %v, %v", stats, pools)
+	}
+
+	set := NewPoolSet(false)
+	set.AddStats("slices", p)
+	set.AddStats("bytes", NewCapacityPools(8, 64))
+	n := 0
+	set.Range(func(string, *CapacityPools) bool { n++; return true })
+	set.RangeStats(func(string, StatsPool) bool { n += 10; return true })
+	if n != 21 {
+		t.Fatalf("expect 1 CapacityPools and 2 pools, but got %d", n)
+	}
+}
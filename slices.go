@@ -0,0 +1,146 @@
+package bytespool
+
+// Pool-aware equivalents of the slices package operations:
+// when they reallocate, the new array comes from the pools and the old one goes back to its class.
+// As with Append, buf must not be used after the call, only the returned byte slice.
+
+// Grow increases the capacity of buf, if necessary, to guarantee space for another n bytes.
+// If n is negative, Grow panics.
+func (p *CapacityPools) Grow(buf []byte, n int) []byte {
+	if n < 0 {
+		panic("bytespool: cannot be negative")
+	}
+	return p.grow(buf, len(buf)+n)
+}
+
+// grow returns buf with a capacity of at least m, the old array is released if it is reallocated.
+func (p *CapacityPools) grow(buf []byte, m int) []byte {
+	if cap(buf) >= m {
+		return buf
+	}
+	nb := p.New(m)[:len(buf)]
+	copy(nb, buf)
+	p.Release(buf)
+	return nb
+}
+
+// Insert inserts the values vs into buf at index i, returning the modified byte slice.
+// The elements at buf[i:] are shifted up to make room. vs must not overlap buf.
+// Insert panics if i is out of range.
+func (p *CapacityPools) Insert(buf []byte, i int, vs ...byte) []byte {
+	_ = buf[i:] // bounds check
+	return p.Replace(buf, i, i, vs...)
+}
+
+// Delete removes the elements buf[i:j] from buf, returning the modified byte slice.
+// It never reallocates. Delete panics if buf[i:j] is not a valid slice of buf.
+func (p *CapacityPools) Delete(buf []byte, i, j int) []byte {
+	_ = buf[i:j] // bounds check
+	return append(buf[:i], buf[j:]...)
+}
+
+// Replace replaces the elements buf[i:j] by the given vs, returning the modified byte slice.
+// vs must not overlap buf. Replace panics if buf[i:j] is not a valid slice of buf.
+func (p *CapacityPools) Replace(buf []byte, i, j int, vs ...byte) []byte {
+	_ = buf[i:j] // bounds check
+	n := len(buf)
+	m := n - (j - i) + len(vs)
+	if m <= cap(buf) {
+		tail := buf[j:]
+		buf = buf[:m]
+		copy(buf[i+len(vs):], tail)
+		copy(buf[i:], vs)
+		return buf
+	}
+	nb := p.New(m)
+	copy(nb, buf[:i])
+	copy(nb[i:], vs)
+	copy(nb[i+len(vs):], buf[j:])
+	p.Release(buf)
+	return nb
+}
+
+// Concat returns a new byte slice concatenating the passed in byte slices.
+func (p *CapacityPools) Concat(bufs ...[]byte) []byte {
+	size := 0
+	for _, b := range bufs {
+		size += len(b)
+	}
+	nb := p.Make(size)
+	for _, b := range bufs {
+		nb = append(nb, b...)
+	}
+	return nb
+}
+
+// Clip moves the data of buf to the smallest class holding it if buf has a larger capacity,
+// the old array is released. Byte slices larger than the maximum capacity are returned as they are.
+func (p *CapacityPools) Clip(buf []byte) []byte {
+	bp := p.getMakePool(len(buf))
+	if bp == nil || cap(buf) <= bp.capacity {
+		return buf
+	}
+	nb := p.New(len(buf))
+	copy(nb, buf)
+	p.Release(buf)
+	return nb
+}
+
+// DefaultShrinkRatio is the default ratio of length to capacity below which Shrink moves a byte slice.
+const DefaultShrinkRatio = 0.25
+
+// Shrink moves the data of buf to the smallest class holding it, like Clip,
+// but only if it uses less than the shrink ratio of its capacity (see SetShrinkRatio),
+// e.g. for a long-lived byte slice that once grew large.
+func (p *CapacityPools) Shrink(buf []byte) []byte {
+	ratio := p.shrinkRatio
+	if ratio <= 0 {
+		ratio = DefaultShrinkRatio
+	}
+	if float64(len(buf)) >= ratio*float64(cap(buf)) {
+		return buf
+	}
+	return p.Clip(buf)
+}
+
+// SetShrinkRatio sets the ratio of length to capacity below which Shrink moves a byte slice,
+// DefaultShrinkRatio if 0.
+// This function is not thread-safe and should be called before any pool operations.
+func (p *CapacityPools) SetShrinkRatio(ratio float64) {
+	p.shrinkRatio = ratio
+}
+
+// Grow increases the capacity of buf through the default pools.
+func Grow(buf []byte, n int) []byte {
+	return Default().Grow(buf, n)
+}
+
+// Insert inserts the values vs into buf at index i through the default pools.
+func Insert(buf []byte, i int, vs ...byte) []byte {
+	return Default().Insert(buf, i, vs...)
+}
+
+// Delete removes the elements buf[i:j] from buf.
+func Delete(buf []byte, i, j int) []byte {
+	return Default().Delete(buf, i, j)
+}
+
+// Replace replaces the elements buf[i:j] by the given vs through the default pools.
+func Replace(buf []byte, i, j int, vs ...byte) []byte {
+	return Default().Replace(buf, i, j, vs...)
+}
+
+// Concat returns a byte slice of the default pools concatenating the passed in byte slices.
+func Concat(bufs ...[]byte) []byte {
+	return Default().Concat(bufs...)
+}
+
+// Clip moves the data of buf to the smallest class of the default pools holding it.
+func Clip(buf []byte) []byte {
+	return Default().Clip(buf)
+}
+
+// Shrink moves the data of buf to a smaller class of the default pools if it uses a small part of it.
+func Shrink(buf []byte) []byte {
+	return Default().Shrink(buf)
+}
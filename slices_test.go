@@ -0,0 +1,110 @@
+package bytespool
+
+import (
+	"testing"
+)
+
+func TestCapacityPools_Grow(t *testing.T) {
+	p := NewCapacityPools(8, 64)
+	p.SetWithStats(true)
+	buf := p.NewString("abc")
+	if buf = p.Grow(buf, 5); cap(buf) != 8 || string(buf) != "abc" {
+		t.Fatalf("expect no reallocation, but got cap %d", cap(buf))
+	}
+	if buf = p.Grow(buf, 10); cap(buf) != 16 || string(buf) != "abc" {
+		t.Fatalf("expect cap 16, but got %d", cap(buf))
+	}
+	if sum := RuntimeStatsSummary(0, p); sum.ReleasedCount != 1 {
+		t.Fatalf("expect the old array is released, but got: %+v", sum)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expect panic on negative count")
+		}
+	}()
+	p.Grow(buf, -1)
+}
+
+func TestCapacityPools_InsertReplaceDelete(t *testing.T) {
+	p := NewCapacityPools(8, 64)
+	p.SetWithStats(true)
+	buf := p.NewString("ace")
+	buf = p.Insert(buf, 1, 'b')
+	buf = p.Insert(buf, 3, 'd')
+	if string(buf) != "abcde" || cap(buf) != 8 {
+		t.Fatalf("expect abcde in place, but got %q, cap %d", buf, cap(buf))
+	}
+	buf = p.Replace(buf, 1, 4, []byte("BCD-BCD")...)
+	if string(buf) != "aBCD-BCDe" || cap(buf) != 16 {
+		t.Fatalf("expect aBCD-BCDe, but got %q, cap %d", buf, cap(buf))
+	}
+	buf = p.Replace(buf, 4, 8, 'x')
+	if string(buf) != "aBCDxe" {
+		t.Fatalf("expect aBCDxe, but got %q", buf)
+	}
+	buf = p.Delete(buf, 1, 4)
+	if string(buf) != "axe" || cap(buf) != 16 {
+		t.Fatalf("expect axe, but got %q, cap %d", buf, cap(buf))
+	}
+	buf = p.Clip(buf)
+	if string(buf) != "axe" || cap(buf) != 8 {
+		t.Fatalf("expect axe clipped to cap 8, but got %q, cap %d", buf, cap(buf))
+	}
+	first := &buf[0]
+	if buf = p.Clip(buf); cap(buf) != 8 || &buf[0] != first || string(buf) != "axe" {
+		t.Fatal("expect no reallocation")
+	}
+	if sum := RuntimeStatsSummary(0, p); sum.ReleasedCount != 2 {
+		t.Fatalf("expect the old arrays are released, but got: %+v", sum)
+	}
+	large := p.New(100)
+	if &p.Clip(large)[0] != &large[0] {
+		t.Fatal("expect the large byte slice is returned as it is")
+	}
+}
+
+func TestCapacityPools_Concat(t *testing.T) {
+	p := NewCapacityPools(8, 64)
+	buf := p.Concat([]byte("foo"), nil, []byte("bar"))
+	if string(buf) != "foobar" || cap(buf) != 8 {
+		t.Fatalf("expect foobar, but got %q, cap %d", buf, cap(buf))
+	}
+}
+
+func TestSliceOperations(t *testing.T) {
+	buf := Concat([]byte("bd"))
+	buf = Insert(buf, 0, 'a')
+	buf = Replace(buf, 2, 3, 'c', 'd')
+	buf = Grow(buf, 100)
+	buf = Delete(buf, 0, 1)
+	if buf = Clip(buf); string(buf) != "bcd" || cap(buf) != 4 {
+		t.Fatalf("expect bcd, but got %q, cap %d", buf, cap(buf))
+	}
+	Release(buf)
+}
+
+func TestCapacityPools_Shrink(t *testing.T) {
+	p := NewCapacityPools(8, 1024)
+	buf := p.New(1000)[:300]
+	if buf = p.Shrink(buf); cap(buf) != 1024 {
+		t.Fatalf("expect no move above the ratio, but got cap %d", cap(buf))
+	}
+	buf = buf[:200]
+	if buf = p.Shrink(buf); len(buf) != 200 || cap(buf) != 256 {
+		t.Fatalf("expect cap 256, but got %d", cap(buf))
+	}
+
+	p.SetShrinkRatio(0.9)
+	buf = buf[:200]
+	if buf = p.Shrink(buf); cap(buf) != 256 {
+		t.Fatal("expect no move to the same class")
+	}
+	buf = buf[:100]
+	if buf = p.Shrink(buf); cap(buf) != 128 {
+		t.Fatalf("expect cap 128 with the custom ratio, but got %d", cap(buf))
+	}
+	p.Release(buf)
+	if buf = Shrink(New(100)[:0]); cap(buf) != 2 {
+		t.Fatalf("expect the smallest class, but got cap %d", cap(buf))
+	}
+}
@@ -1,18 +1,27 @@
 package bytespool
 
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"text/tabwriter"
+)
+
 // SetWithStats enables or disables statistics collection.
 // When enabled, statistics will be collected, but this may affect performance.
 // When disabled (default), all atomic operations for statistics are skipped for better performance.
 // This function is not thread-safe and should be called before any pool operations.
 func SetWithStats(t bool) {
-	DefaultCapacityPools.SetWithStats(t)
+	Default().SetWithStats(t)
 }
 
 // GetWithStats returns the current status of statistics collection.
 // When true, statistics are being collected.
 // When false (default), statistics are not being collected.
 func GetWithStats() bool {
-	return DefaultCapacityPools.GetWithStats()
+	return Default().GetWithStats()
 }
 
 // RuntimeStats returns runtime statistics for byte pools.
@@ -25,7 +34,7 @@ func GetWithStats() bool {
 // The statistics collection can be enabled/disabled with SetWithStats().
 // When disabled (default), all counters will be zero.
 func RuntimeStats(ps ...*CapacityPools) map[string]uint64 {
-	p := DefaultCapacityPools
+	p := Default()
 	if len(ps) > 0 {
 		p = ps[0]
 	}
@@ -47,19 +56,40 @@ func RuntimeStats(ps ...*CapacityPools) map[string]uint64 {
 }
 
 // RuntimeSummary is a structured summary of runtime pool statistics.
-// It contains global byte counters and the top pools by reuse hits.
+// It contains global byte counters, the top pools by reuse hits
+// and the windowed rates when the sampler is running.
+// The JSON field names are stable.
 type RuntimeSummary struct {
-	NewBytes    uint64     // total bytes newly allocated for pools
-	OutBytes    uint64     // total bytes allocated outside pools
-	OutCount    uint64     // total number of bytes allocated outside pools
-	ReusedBytes uint64     // total bytes reused from pools
-	TopPools    []PoolStat // top pools by reuse hits (ranked)
+	NewBytes      uint64         `json:"NewBytes"`      // total bytes newly allocated for pools
+	NewCount      uint64         `json:"NewCount"`      // total number of byte slices newly allocated for pools
+	OutBytes      uint64         `json:"OutBytes"`      // total bytes allocated outside pools
+	OutCount      uint64         `json:"OutCount"`      // total number of bytes allocated outside pools
+	ReusedBytes   uint64         `json:"ReusedBytes"`   // total bytes reused from pools, including OverflowBytes
+	ReusedCount   uint64         `json:"ReusedCount"`   // total number of byte slices reused from pools, including OverflowHits
+	ReleasedCount uint64         `json:"ReleasedCount"` // total number of byte slices put back into pools
+	DiscardCount  uint64         `json:"DiscardCount"`  // total number of byte slices discarded by Release
+	Outstanding   uint64         `json:"Outstanding"`   // number of pooled byte slices not yet released
+	TrimmedBytes  uint64         `json:"TrimmedBytes"`  // total bytes of cached byte slices freed by Trim, Drain or the janitor
+	TrimmedCount  uint64         `json:"TrimmedCount"`  // total number of cached byte slices freed by Trim, Drain or the janitor
+	OverflowHits  uint64         `json:"OverflowHits"`  // total number of byte slices reused from the overflow tier
+	OverflowBytes uint64         `json:"OverflowBytes"` // total capacity bytes reused from the overflow tier
+	OverflowEvict uint64         `json:"OverflowEvict"` // total number of byte slices evicted from the overflow tier
+	OverflowCache uint64         `json:"OverflowCache"` // capacity bytes currently cached by the overflow tier
+	TopPools      []PoolStat     `json:"TopPools"`      // top pools by reuse hits (ranked)
+	Tags          []TagStat      `json:"Tags"`          // per-tag breakdown ordered by tag, nil if no TaggedPool is used
+	Lifetimes     []LifetimeStat `json:"Lifetimes"`     // hold durations per capacity, nil unless SetWithLifetime is enabled
+	Rates         []Rate         `json:"Rates"`         // windowed rates, nil if the sampler is not running
 }
 
 // RuntimeStatsSummary returns a structured RuntimeSummary for the provided
 // CapacityPools (or the default pools when none provided).
 func RuntimeStatsSummary(topN int, ps ...*CapacityPools) RuntimeSummary {
-	p := DefaultCapacityPools
+	return RuntimeStatsSummaryBy(topN, SortByReuseHits, ps...)
+}
+
+// RuntimeStatsSummaryBy is like RuntimeStatsSummary, but TopPools is ordered by the given key.
+func RuntimeStatsSummaryBy(topN int, by SortBy, ps ...*CapacityPools) RuntimeSummary {
+	p := Default()
 	if len(ps) > 0 {
 		p = ps[0]
 	}
@@ -69,28 +99,175 @@ func RuntimeStatsSummary(topN int, ps ...*CapacityPools) RuntimeSummary {
 	}
 
 	summary := RuntimeSummary{
-		NewBytes:    p.getTotalNewBytes(),
-		OutBytes:    p.getTotalOutBytes(),
-		OutCount:    p.getOutCount(),
-		ReusedBytes: p.getTotalReusedBytes(),
+		NewBytes:      p.getTotalNewBytes(),
+		NewCount:      p.getNewCount(),
+		OutBytes:      p.getTotalOutBytes(),
+		OutCount:      p.getOutCount(),
+		ReusedBytes:   p.getTotalReusedBytes(),
+		ReusedCount:   p.getReusedCount(),
+		ReleasedCount: p.getReleasedCount(),
+		DiscardCount:  p.getDiscardCount(),
+		TrimmedBytes:  atomic.LoadUint64(&p.trimmedBytes),
+		TrimmedCount:  atomic.LoadUint64(&p.trimmedCount),
+	}
+	if o := p.overflow; o != nil {
+		summary.OverflowHits = p.getOverflowHits()
+		summary.OverflowBytes = atomic.LoadUint64(&o.hitBytes)
+		summary.OverflowEvict = atomic.LoadUint64(&o.evictions)
+		summary.OverflowCache = uint64(o.cachedBytes())
 	}
+	// the overflow tier also caches byte slices allocated outside pools, its hits are not outstanding
+	summary.Outstanding = outstanding(summary.NewCount+summary.ReusedCount-summary.OverflowHits, summary.ReleasedCount)
 	if topN > 0 {
-		summary.TopPools = p.getPoolReuseStats(topN)
+		summary.TopPools = p.getPoolStats(topN, by)
 	}
+	summary.Tags = p.getTagStats()
+	summary.Lifetimes = p.getLifetimeStats()
+	summary.Rates = p.Rates()
 	return summary
 }
 
+// StatsSummaryBy is RuntimeStatsSummaryBy of p, see StatsPool.
+func (p *CapacityPools) StatsSummaryBy(topN int, by SortBy) RuntimeSummary {
+	return RuntimeStatsSummaryBy(topN, by, p)
+}
+
+// PoolStats is PoolStats of p, see StatsPool.
+func (p *CapacityPools) PoolStats() []PoolStat {
+	return PoolStats(p)
+}
+
+// String returns a human-readable table of the summary.
+func (s RuntimeSummary) String() string {
+	var sb strings.Builder
+	_, _ = s.WriteTo(&sb)
+	return sb.String()
+}
+
+// WriteTo writes the human-readable table of the summary to w.
+func (s RuntimeSummary) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	fmt.Fprintf(cw, "new: %d (%s), reused: %d (%s), out: %d (%s)\n",
+		s.NewCount, formatBytes(s.NewBytes),
+		s.ReusedCount, formatBytes(s.ReusedBytes),
+		s.OutCount, formatBytes(s.OutBytes))
+	fmt.Fprintf(cw, "released: %d, discarded: %d, outstanding: %d\n",
+		s.ReleasedCount, s.DiscardCount, s.Outstanding)
+	if s.TrimmedCount > 0 {
+		fmt.Fprintf(cw, "trimmed: %d (%s)\n", s.TrimmedCount, formatBytes(s.TrimmedBytes))
+	}
+	if s.OverflowHits > 0 || s.OverflowCache > 0 {
+		fmt.Fprintf(cw, "overflow hits: %d (%s), evicted: %d, cached: %s\n",
+			s.OverflowHits, formatBytes(s.OverflowBytes), s.OverflowEvict, formatBytes(s.OverflowCache))
+	}
+	if len(s.TopPools) > 0 {
+		tw := tabwriter.NewWriter(cw, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(tw, "rank\tclass\thits\tmisses\tbytes\twaste\treuse\toutstanding\t")
+		for _, st := range s.TopPools {
+			fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%s\t%s\t%.1f%%\t%d\t\n",
+				st.Rank, formatBytes(uint64(st.Capacity)), st.ReuseHits, st.Misses,
+				formatBytes(st.Bytes), formatBytes(st.Waste), st.ReuseRatio*100, st.Outstanding)
+		}
+		_ = tw.Flush()
+	}
+	if len(s.Tags) > 0 {
+		tw := tabwriter.NewWriter(cw, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(tw, "tag\tgets\tbytes\treleases\toutstanding\toutstanding bytes\t")
+		for _, st := range s.Tags {
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%d\t%d\t%s\t\n",
+				st.Tag, st.Gets, formatBytes(st.Bytes), st.Releases, st.Outstanding, formatBytes(st.OutstandingBytes))
+		}
+		_ = tw.Flush()
+	}
+	if len(s.Lifetimes) > 0 {
+		tw := tabwriter.NewWriter(cw, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(tw, "class\tholds\tp50\tp99\tmax\t")
+		for _, st := range s.Lifetimes {
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t\n",
+				formatBytes(uint64(st.Capacity)), st.Count, st.P50, st.P99, st.Max)
+		}
+		_ = tw.Flush()
+	}
+	for _, r := range s.Rates {
+		fmt.Fprintln(cw, r.String())
+	}
+	return cw.n, cw.err
+}
+
 // PoolStat represents a pool statistic entry
 type PoolStat struct {
-	Rank      int
-	Capacity  int
-	ReuseHits uint64
+	Rank        int     `json:"Rank"`
+	Capacity    int     `json:"Capacity"`    // capacity of the byte slices in this pool
+	ReuseHits   uint64  `json:"ReuseHits"`   // number of times byte slices were reused from this pool
+	Misses      uint64  `json:"Misses"`      // number of times byte slices were newly allocated for this pool
+	Bytes       uint64  `json:"Bytes"`       // capacity bytes handed out from this pool
+	Waste       uint64  `json:"Waste"`       // capacity bytes handed out but not requested
+	ReuseRatio  float64 `json:"ReuseRatio"`  // ReuseHits / (ReuseHits + Misses)
+	MissRatio   float64 `json:"MissRatio"`   // Misses / (ReuseHits + Misses)
+	Releases    uint64  `json:"Releases"`    // number of byte slices put back into this pool
+	Outstanding uint64  `json:"Outstanding"` // number of byte slices not yet put back into this pool
+}
+
+// String returns a single line description of the pool statistic.
+func (st PoolStat) String() string {
+	return fmt.Sprintf("rank=%d class=%s hits=%d misses=%d bytes=%s waste=%s reuse=%.1f%%",
+		st.Rank, formatBytes(uint64(st.Capacity)), st.ReuseHits, st.Misses,
+		formatBytes(st.Bytes), formatBytes(st.Waste), st.ReuseRatio*100)
+}
+
+// String returns a single line description of the rate.
+func (r Rate) String() string {
+	return fmt.Sprintf("window=%s allocs/s=%.2f new/s=%.2f out/s=%.2f reused/s=%.2f reuse=%.1f%%",
+		r.Window, r.AllocsPerSec, r.NewPerSec, r.OutPerSec, r.ReusedPerSec, r.ReuseRatio*100)
+}
+
+// SortBy is the ordering key of the top pool statistics.
+type SortBy int
+
+const (
+	SortByReuseHits SortBy = iota // most reuse hits first
+	SortByBytes                   // most capacity bytes handed out first
+	SortByMissRatio               // highest miss ratio first
+	SortByWaste                   // most unrequested capacity bytes first
+)
+
+func (by SortBy) String() string {
+	switch by {
+	case SortByReuseHits:
+		return "reuseHits"
+	case SortByBytes:
+		return "bytes"
+	case SortByMissRatio:
+		return "missRatio"
+	case SortByWaste:
+		return "waste"
+	}
+	return "SortBy(" + strconv.Itoa(int(by)) + ")"
+}
+
+// less reports whether a ranks below b.
+func (by SortBy) less(a, b PoolStat) bool {
+	switch by {
+	case SortByBytes:
+		return a.Bytes < b.Bytes
+	case SortByMissRatio:
+		return a.MissRatio < b.MissRatio
+	case SortByWaste:
+		return a.Waste < b.Waste
+	}
+	return a.ReuseHits < b.ReuseHits
 }
 
 // PoolReuseStats returns the top N pool reuse statistics (by reuse hits).
 // If n <= 0 it returns an empty slice.
 func PoolReuseStats(topN int, ps ...*CapacityPools) []PoolStat {
-	p := DefaultCapacityPools
+	return PoolStatsBy(topN, SortByReuseHits, ps...)
+}
+
+// PoolStatsBy returns the top N pool statistics ordered by the given key.
+// If n <= 0 it returns an empty slice.
+func PoolStatsBy(topN int, by SortBy, ps ...*CapacityPools) []PoolStat {
+	p := Default()
 	if len(ps) > 0 {
 		p = ps[0]
 	}
@@ -99,5 +276,59 @@ func PoolReuseStats(topN int, ps ...*CapacityPools) []PoolStat {
 		return nil
 	}
 
-	return p.getPoolReuseStats(topN)
+	return p.getPoolStats(topN, by)
+}
+
+// PoolStats returns the statistics of every pool in ascending capacity order, Rank is not set.
+// It returns nil if statistics collection is disabled.
+func PoolStats(ps ...*CapacityPools) []PoolStat {
+	p := Default()
+	if len(ps) > 0 {
+		p = ps[0]
+	}
+
+	if !p.GetWithStats() {
+		return nil
+	}
+
+	stats := make([]PoolStat, 0, len(p.pools))
+	for _, bp := range p.pools {
+		stats = append(stats, bp.stat())
+	}
+	return stats
+}
+
+// formatBytes returns a human-readable IEC representation of n.
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return strconv.FormatUint(n, 10) + " B"
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit && exp < 5; m /= unit {
+		div *= unit
+		exp++
+	}
+	v := float64(n) / float64(div)
+	if n%div == 0 {
+		return strconv.FormatUint(n/div, 10) + " " + "KMGTPE"[exp:exp+1] + "iB"
+	}
+	return strconv.FormatFloat(v, 'f', 1, 64) + " " + "KMGTPE"[exp:exp+1] + "iB"
+}
+
+// countWriter counts the bytes written and keeps the first error.
+type countWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+	return n, err
 }
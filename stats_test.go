@@ -1,11 +1,14 @@
 package bytespool
 
 import (
+	"encoding/json"
 	"runtime/debug"
+	"strings"
 	"testing"
 )
 
 func TestRuntimeStats(t *testing.T) {
+	skipNoPool(t)
 	var n, b, r uint64
 	p := NewCapacityPools(2, 128)
 	p.SetWithStats(true)
@@ -95,3 +98,155 @@ func TestRuntimeStatsSummary(t *testing.T) {
 		}
 	}
 }
+
+func TestPoolStatsBy(t *testing.T) {
+	skipNoPool(t)
+	p := NewCapacityPools(8, 1024)
+	p.SetWithStats(true)
+	gc := debug.SetGCPercent(-1)
+	defer debug.SetGCPercent(gc)
+
+	// class 16: 1 miss, 3 hits, waste 4*(16-9)
+	for i := 0; i < 4; i++ {
+		p.Release(p.New(9))
+	}
+	// class 1024: 2 misses, 0 hits
+	_ = p.New(1000)
+	_ = p.New(1024)
+
+	stats := PoolStatsBy(10, SortByReuseHits, p)
+	if len(stats) == 0 || stats[0].Capacity != 16 || stats[0].ReuseHits+stats[0].Misses != 4 {
+		t.Fatalf("unexpected reuse stats: %v", stats)
+	}
+	st := stats[0]
+	if st.Bytes != 64 || st.Waste != 28 {
+		t.Fatalf("unexpected pool stat: %+v", st)
+	}
+	if !raceEnabled {
+		if len(stats) != 1 || st.ReuseHits != 3 || st.Misses != 1 || st.ReuseRatio != 0.75 || st.MissRatio != 0.25 {
+			t.Fatalf("unexpected pool stat: %+v", st)
+		}
+	}
+
+	stats = PoolStatsBy(10, SortByBytes, p)
+	if len(stats) != 2 || stats[0].Capacity != 1024 || stats[1].Capacity != 16 || stats[1].Rank != 2 {
+		t.Fatalf("unexpected bytes stats: %v", stats)
+	}
+	stats = PoolStatsBy(1, SortByMissRatio, p)
+	if len(stats) != 1 || stats[0].Capacity != 1024 || stats[0].MissRatio != 1 {
+		t.Fatalf("unexpected miss ratio stats: %v", stats)
+	}
+	stats = PoolStatsBy(10, SortByWaste, p)
+	if stats[0].Capacity != 16 || stats[1].Waste != 24 {
+		t.Fatalf("unexpected waste stats: %v", stats)
+	}
+	if SortByWaste.String() != "waste" || SortBy(9).String() != "SortBy(9)" {
+		t.Fatal("unexpected SortBy names")
+	}
+}
+
+func TestRuntimeSummary_Marshal(t *testing.T) {
+	skipNoPool(t)
+	p := NewCapacityPools(8, 1024)
+	p.SetWithStats(true)
+	p.Release(p.New(100))
+	_ = p.New(100)
+	_ = p.New(2048)
+
+	summary := RuntimeStatsSummary(5, p)
+	js, err := json.Marshal(summary)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var m map[string]interface{}
+	if err = json.Unmarshal(js, &m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, key := range []string{"NewBytes", "NewCount", "OutBytes", "OutCount", "ReusedBytes", "ReusedCount", "TopPools", "Rates"} {
+		if _, ok := m[key]; !ok {
+			t.Errorf("Missing required key: %s", key)
+		}
+	}
+
+	var got RuntimeSummary
+	if err = json.Unmarshal(js, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.OutBytes != 2048 || len(got.TopPools) != len(summary.TopPools) {
+		t.Fatalf("unexpected round trip result: %+v", got)
+	}
+
+	s := summary.String()
+	if !strings.Contains(s, "out: 1 (2 KiB)") {
+		t.Fatalf("unexpected summary table:\n%s", s)
+	}
+	if !raceEnabled && !strings.Contains(s, "class") {
+		t.Fatalf("expect the reused class in the summary table:\n%s", s)
+	}
+	if len(summary.TopPools) > 0 && !strings.Contains(summary.TopPools[0].String(), "class=128 B") {
+		t.Fatalf("unexpected pool stat: %s", summary.TopPools[0])
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[uint64]string{
+		0:                      "0 B",
+		1023:                   "1023 B",
+		1024:                   "1 KiB",
+		1536:                   "1.5 KiB",
+		4 * 1024 * 1024:        "4 MiB",
+		3 * 1024 * 1024 * 1024: "3 GiB",
+	}
+	for n, want := range tests {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d): expect %q, but got %q", n, want, got)
+		}
+	}
+}
+
+func TestPoolStats(t *testing.T) {
+	p := NewCapacityPools(8, 64)
+	if PoolStats(p) != nil {
+		t.Fatal("expect nil when statistics collection is disabled")
+	}
+	p.SetWithStats(true)
+	_ = p.New(20)
+	stats := PoolStats(p)
+	if len(stats) != 4 {
+		t.Fatalf("expect 4 pools, but got %d", len(stats))
+	}
+	for i, st := range stats {
+		if st.Capacity != 8<<i {
+			t.Fatalf("expect capacity %d, but got %d", 8<<i, st.Capacity)
+		}
+	}
+	if stats[2].Misses != 1 || stats[2].Waste != 12 {
+		t.Fatalf("unexpected pool stat: %+v", stats[2])
+	}
+}
+
+func TestRuntimeSummary_Outstanding(t *testing.T) {
+	p := NewCapacityPools(8, 64)
+	p.SetWithStats(true)
+	a := p.New(8)
+	b := p.New(20)
+	_ = p.New(20)
+	p.Release(a)
+	p.Release(b)
+	p.Release(make([]byte, 4))
+	p.Release(make([]byte, 100))
+
+	summary := RuntimeStatsSummary(10, p)
+	if summary.ReleasedCount != 2 || summary.DiscardCount != 2 || summary.Outstanding != 1 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+	for _, st := range PoolStats(p) {
+		want := uint64(0)
+		if st.Capacity == 32 {
+			want = 1
+		}
+		if st.Outstanding != want {
+			t.Fatalf("expect %d outstanding in class %d, but got %d", want, st.Capacity, st.Outstanding)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+// Package statsd periodically pushes bytespool statistics to a StatsD or DogStatsD collector over UDP.
+package statsd
+
+import (
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fufuok/bytespool"
+	// registers the buffer pools
+	_ "github.com/fufuok/bytespool/buffer"
+)
+
+const (
+	// DefaultAddr is the default collector address.
+	DefaultAddr = "127.0.0.1:8125"
+
+	// DefaultPrefix is the default prefix of the metric names.
+	DefaultPrefix = "bytespool."
+
+	// DefaultInterval is the default reporting interval.
+	DefaultInterval = 10 * time.Second
+
+	// DefaultMaxPacketSize keeps packets below the typical Ethernet MTU.
+	DefaultMaxPacketSize = 1432
+
+	// DefaultPoolName is the pool name of bytespool.Default().
+	DefaultPoolName = bytespool.DefaultPoolName
+
+	// BufferPoolName is the pool name of the buffer package pools.
+	BufferPoolName = bytespool.BufferPoolName
+)
+
+// Format is the wire format of the metrics.
+type Format int
+
+const (
+	// FormatStatsD puts the pool name into the metric name: bytespool.default.new:1|c
+	FormatStatsD Format = iota
+	// FormatDogStatsD puts the pool name into a tag: bytespool.new:1|c|#pool:default
+	FormatDogStatsD
+)
+
+// Config is the configuration of a Reporter.
+type Config struct {
+	Addr          string        // collector address, DefaultAddr if empty
+	Prefix        string        // metric name prefix, DefaultPrefix if empty
+	Tags          []string      // extra DogStatsD tags, e.g. "env:prod"
+	Format        Format        // wire format
+	Interval      time.Duration // reporting interval, DefaultInterval if <= 0
+	MaxPacketSize int           // maximum UDP payload, DefaultMaxPacketSize if <= 0
+}
+
+// Reporter pushes the statistics of a set of named CapacityPools.
+// Counters are sent as the increase since the previous report, gauges as the current value.
+// The statistics collection of each pool must be enabled with SetWithStats,
+// otherwise all its counters are zero.
+type Reporter struct {
+	cfg   Config
+	conn  net.Conn
+	pools *bytespool.PoolSet
+
+	mu   sync.Mutex
+	last map[string]bytespool.RuntimeSummary // by pool name, to report counter deltas
+	buf  []byte
+
+	started bool
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+// New returns a Reporter of every registered pool, including the default pools and the buffer pools,
+// followed by the added pools, see bytespool.NewPoolSet.
+func New(cfg Config) (*Reporter, error) {
+	return newReporter(cfg, bytespool.NewPoolSet(true))
+}
+
+// NewEmpty returns a Reporter without any pools.
+func NewEmpty(cfg Config) (*Reporter, error) {
+	return newReporter(cfg, bytespool.NewPoolSet(false))
+}
+
+func newReporter(cfg Config, pools *bytespool.PoolSet) (*Reporter, error) {
+	if cfg.Addr == "" {
+		cfg.Addr = DefaultAddr
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = DefaultPrefix
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	if cfg.MaxPacketSize <= 0 {
+		cfg.MaxPacketSize = DefaultMaxPacketSize
+	}
+	conn, err := net.Dial("udp", cfg.Addr)
+	if err != nil {
+		return nil, err
+	}
+	return &Reporter{
+		cfg:   cfg,
+		conn:  conn,
+		pools: pools,
+		last:  make(map[string]bytespool.RuntimeSummary),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}, nil
+}
+
+// Add registers p under name.
+func (r *Reporter) Add(name string, p *bytespool.CapacityPools) *Reporter {
+	return r.AddFunc(name, func() *bytespool.CapacityPools { return p })
+}
+
+// AddFunc registers a pool resolved at every report under name.
+// Registering an existing name replaces it.
+func (r *Reporter) AddFunc(name string, fn func() *bytespool.CapacityPools) *Reporter {
+	r.pools.AddFunc(name, fn)
+	return r
+}
+
+// AddStats registers a pool of another kind, e.g. a bytespool.SlicePool, under name.
+func (r *Reporter) AddStats(name string, p bytespool.StatsPool) *Reporter {
+	r.pools.AddStats(name, p)
+	return r
+}
+
+// Start reports every Config.Interval in a background goroutine until Stop is called.
+// Calling Start more than once has no effect.
+func (r *Reporter) Start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.started {
+		return
+	}
+	r.started = true
+	go func() {
+		defer close(r.done)
+		t := time.NewTicker(r.cfg.Interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-t.C:
+				_ = r.Report()
+			}
+		}
+	}()
+}
+
+// Stop stops the background reporting, sends a final report and closes the connection.
+// Stop must only be called once; the Reporter cannot be reused.
+func (r *Reporter) Stop() error {
+	var err error
+	r.once.Do(func() {
+		close(r.stop)
+		r.mu.Lock()
+		started := r.started
+		r.mu.Unlock()
+		if started {
+			<-r.done
+		}
+		err = r.Report()
+		if cerr := r.conn.Close(); err == nil {
+			err = cerr
+		}
+	})
+	return err
+}
+
+// Report sends the current statistics once.
+func (r *Reporter) Report() (err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.buf = r.buf[:0]
+	r.pools.RangeStats(func(name string, p bytespool.StatsPool) bool {
+		err = r.report(name, p)
+		return err == nil
+	})
+	if err != nil {
+		return
+	}
+	return r.flush()
+}
+
+// report appends the metrics of one pool, r.mu must be held.
+func (r *Reporter) report(name string, p bytespool.StatsPool) (err error) {
+	cur := p.StatsSummaryBy(0, bytespool.SortByReuseHits)
+	prev := r.last[name]
+	r.last[name] = cur
+
+	for _, m := range []struct {
+		name    string
+		cur, pv uint64
+	}{
+		{"new", cur.NewCount, prev.NewCount},
+		{"new_bytes", cur.NewBytes, prev.NewBytes},
+		{"reused", cur.ReusedCount, prev.ReusedCount},
+		{"reused_bytes", cur.ReusedBytes, prev.ReusedBytes},
+		{"out", cur.OutCount, prev.OutCount},
+		{"out_bytes", cur.OutBytes, prev.OutBytes},
+		{"released", cur.ReleasedCount, prev.ReleasedCount},
+		{"discarded", cur.DiscardCount, prev.DiscardCount},
+		{"trimmed", cur.TrimmedCount, prev.TrimmedCount},
+		{"trimmed_bytes", cur.TrimmedBytes, prev.TrimmedBytes},
+		{"overflow_hits", cur.OverflowHits, prev.OverflowHits},
+		{"overflow_hit_bytes", cur.OverflowBytes, prev.OverflowBytes},
+		{"overflow_evictions", cur.OverflowEvict, prev.OverflowEvict},
+	} {
+		// counters restart from zero when the pool is replaced
+		delta := m.cur
+		if m.cur >= m.pv {
+			delta = m.cur - m.pv
+		}
+		if err = r.add(name, m.name, strconv.FormatUint(delta, 10), "c"); err != nil {
+			return err
+		}
+	}
+	if err = r.add(name, "outstanding", strconv.FormatUint(cur.Outstanding, 10), "g"); err != nil {
+		return err
+	}
+	if err = r.add(name, "overflow_cached_bytes", strconv.FormatUint(cur.OverflowCache, 10), "g"); err != nil {
+		return err
+	}
+	for _, rate := range cur.Rates {
+		w := rate.Window.String()
+		if err = r.add(name, "allocs_per_sec", formatFloat(rate.AllocsPerSec), "g", w); err != nil {
+			return err
+		}
+		if err = r.add(name, "reuse_ratio", formatFloat(rate.ReuseRatio), "g", w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add appends one metric line, sending the packet first if it would become too large.
+// A non-empty window is appended to the name for StatsD, or sent as a tag for DogStatsD.
+func (r *Reporter) add(pool, name, value, typ string, window ...string) error {
+	start := len(r.buf)
+	if start > 0 {
+		r.buf = append(r.buf, '\n')
+	}
+	r.buf = append(r.buf, r.cfg.Prefix...)
+	if r.cfg.Format == FormatStatsD {
+		r.buf = appendName(r.buf, pool)
+		r.buf = append(r.buf, '.')
+	}
+	r.buf = append(r.buf, name...)
+	if len(window) > 0 && r.cfg.Format == FormatStatsD {
+		r.buf = append(r.buf, '.')
+		r.buf = append(r.buf, window[0]...)
+	}
+	r.buf = append(r.buf, ':')
+	r.buf = append(r.buf, value...)
+	r.buf = append(r.buf, '|')
+	r.buf = append(r.buf, typ...)
+	if r.cfg.Format == FormatDogStatsD {
+		r.buf = append(r.buf, "|#pool:"...)
+		r.buf = appendName(r.buf, pool)
+		if len(window) > 0 {
+			r.buf = append(r.buf, ",window:"...)
+			r.buf = append(r.buf, window[0]...)
+		}
+		for _, tag := range r.cfg.Tags {
+			r.buf = append(r.buf, ',')
+			r.buf = append(r.buf, tag...)
+		}
+	}
+	if len(r.buf) <= r.cfg.MaxPacketSize || start == 0 {
+		return nil
+	}
+
+	// send the previous lines, keep the new one
+	line := append([]byte(nil), r.buf[start+1:]...)
+	r.buf = r.buf[:start]
+	if err := r.flush(); err != nil {
+		return err
+	}
+	r.buf = append(r.buf[:0], line...)
+	return nil
+}
+
+func (r *Reporter) flush() error {
+	if len(r.buf) == 0 {
+		return nil
+	}
+	_, err := r.conn.Write(r.buf)
+	r.buf = r.buf[:0]
+	return err
+}
+
+// appendName appends the pool name with the characters of the wire format replaced by '_'.
+func appendName(dst []byte, name string) []byte {
+	for i := 0; i < len(name); i++ {
+		switch c := name[i]; c {
+		case ':', '|', '@', ',', '#', ' ', '\n':
+			dst = append(dst, '_')
+		default:
+			dst = append(dst, c)
+		}
+	}
+	return dst
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,152 @@
+package statsd
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fufuok/bytespool"
+)
+
+func listen(t *testing.T) net.PacketConn {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return conn
+}
+
+func readPacket(t *testing.T, conn net.PacketConn) string {
+	buf := make([]byte, 65536)
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return string(buf[:n])
+}
+
+func TestReporter_StatsD(t *testing.T) {
+	if bytespool.NoPool {
+		t.Skip("byte slices are not reused with the bytespool_nopool tag")
+	}
+	conn := listen(t)
+	defer conn.Close()
+
+	p := bytespool.NewCapacityPools(8, 64)
+	p.SetWithStats(true)
+	p.Release(p.New(20))
+	_ = p.New(100)
+
+	r, err := NewEmpty(Config{Addr: conn.LocalAddr().String()})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r.Add("api", p)
+	if err = r.Report(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := readPacket(t, conn)
+	for _, want := range []string{
+		"bytespool.api.new:1|c\n",
+		"bytespool.api.new_bytes:32|c\n",
+		"bytespool.api.out_bytes:100|c\n",
+		"bytespool.api.released:1|c\n",
+		"bytespool.api.outstanding:0|g",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	// counters are sent as deltas
+	_ = p.New(20)
+	if err = r.Report(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out = readPacket(t, conn)
+	wants := []string{
+		"bytespool.api.out_bytes:0|c\n",
+		"bytespool.api.outstanding:1|g",
+	}
+	if !raceEnabled {
+		wants = append(wants, "bytespool.api.new:0|c\n", "bytespool.api.reused:1|c\n")
+	}
+	for _, want := range wants {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if err = r.Stop(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestReporter_DogStatsD(t *testing.T) {
+	conn := listen(t)
+	defer conn.Close()
+
+	p := bytespool.NewCapacityPools(8, 64)
+	p.SetWithStats(true)
+	p.StartSampler(time.Second, time.Minute)
+	defer p.StopSampler()
+
+	r, err := NewEmpty(Config{
+		Addr:     conn.LocalAddr().String(),
+		Prefix:   "app.pool.",
+		Tags:     []string{"env:test"},
+		Format:   FormatDogStatsD,
+		Interval: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r.Add("api|v1,x", p)
+	r.Start()
+	r.Start()
+
+	out := readPacket(t, conn)
+	for _, want := range []string{
+		"app.pool.new:0|c|#pool:api_v1_x,env:test\n",
+		"app.pool.reuse_ratio:0|g|#pool:api_v1_x,window:1m0s,env:test",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if err = r.Stop(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestReporter_MaxPacketSize(t *testing.T) {
+	conn := listen(t)
+	defer conn.Close()
+
+	r, err := New(Config{Addr: conn.LocalAddr().String(), MaxPacketSize: 64})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = r.Report(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 9 metrics of the default pools, the buffer pools are the same pools
+	lines := 0
+	for lines < 9 {
+		out := readPacket(t, conn)
+		if len(out) > 64 {
+			t.Fatalf("expect packets <= 64 bytes, but got %d", len(out))
+		}
+		for _, line := range strings.Split(out, "\n") {
+			if !strings.HasPrefix(line, "bytespool.default.") {
+				t.Fatalf("unexpected metric: %q", line)
+			}
+			lines++
+		}
+	}
+	if lines != 9 {
+		t.Fatalf("expect 9 metrics, but got %d", lines)
+	}
+	_ = r.Stop()
+}
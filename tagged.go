@@ -0,0 +1,176 @@
+package bytespool
+
+import (
+	"sort"
+	"sync/atomic"
+)
+
+// TaggedPool is a lightweight view over a CapacityPools that attributes
+// gets, bytes and outstanding byte slices to a tag, e.g. a subsystem name.
+// The byte slices come from and go back to the underlying pools, which are not split.
+// Like the pool statistics, the tag counters are only collected when SetWithStats is enabled.
+type TaggedPool struct {
+	p            *CapacityPools
+	tag          string
+	gets         uint64 // Number of byte slices acquired with this tag
+	bytes        uint64 // Capacity bytes acquired with this tag
+	releases     uint64 // Number of byte slices released with this tag
+	releaseBytes uint64 // Capacity bytes released with this tag
+}
+
+// TagStat is the statistic of a tag.
+type TagStat struct {
+	Tag              string `json:"Tag"`
+	Gets             uint64 `json:"Gets"`             // number of byte slices acquired
+	Bytes            uint64 `json:"Bytes"`            // capacity bytes acquired
+	Releases         uint64 `json:"Releases"`         // number of byte slices released
+	Outstanding      uint64 `json:"Outstanding"`      // number of byte slices not yet released
+	OutstandingBytes uint64 `json:"OutstandingBytes"` // capacity bytes not yet released
+}
+
+// Tagged returns the view of this pool for tag.
+// The same tag always returns the same view, which is safe for concurrent use.
+func (p *CapacityPools) Tagged(tag string) *TaggedPool {
+	if v, ok := p.tags.Load(tag); ok {
+		return v.(*TaggedPool)
+	}
+	v, _ := p.tags.LoadOrStore(tag, &TaggedPool{p: p, tag: tag})
+	return v.(*TaggedPool)
+}
+
+// Tag returns the tag of the view.
+func (t *TaggedPool) Tag() string {
+	return t.tag
+}
+
+// Pools returns the underlying pools.
+func (t *TaggedPool) Pools() *CapacityPools {
+	return t.p
+}
+
+// New is CapacityPools.New accounted to the tag.
+func (t *TaggedPool) New(size int) []byte {
+	buf := t.p.New(size)
+	if t.p.withStats {
+		atomic.AddUint64(&t.gets, 1)
+		atomic.AddUint64(&t.bytes, uint64(cap(buf)))
+	}
+	return buf
+}
+
+// Make is CapacityPools.Make accounted to the tag.
+func (t *TaggedPool) Make(capacity int) []byte {
+	return t.New(capacity)[:0]
+}
+
+// NewBytes returns a byte slice of the specified content accounted to the tag.
+func (t *TaggedPool) NewBytes(bs []byte) []byte {
+	buf := t.Make(len(bs))
+	return append(buf, bs...)
+}
+
+// NewString returns a byte slice of the specified content accounted to the tag.
+func (t *TaggedPool) NewString(s string) []byte {
+	buf := t.Make(len(s))
+	return append(buf, s...)
+}
+
+// Append is CapacityPools.Append accounted to the tag.
+func (t *TaggedPool) Append(buf []byte, elems ...byte) []byte {
+	size, ok := t.p.appendSize(cap(buf), len(buf)+len(elems))
+	if !ok {
+		return append(buf, elems...)
+	}
+	bbuf := t.Make(size)
+	bbuf = append(bbuf, buf...)
+	bbuf = append(bbuf, elems...)
+	t.Release(buf)
+	return bbuf
+}
+
+// AppendString is CapacityPools.AppendString accounted to the tag.
+func (t *TaggedPool) AppendString(buf []byte, elems string) []byte {
+	size, ok := t.p.appendSize(cap(buf), len(buf)+len(elems))
+	if !ok {
+		return append(buf, elems...)
+	}
+	bbuf := t.Make(size)
+	bbuf = append(bbuf, buf...)
+	bbuf = append(bbuf, elems...)
+	t.Release(buf)
+	return bbuf
+}
+
+// Release is CapacityPools.Release accounted to the tag.
+// Byte slices must be released with the tag they were acquired with.
+func (t *TaggedPool) Release(buf []byte) bool {
+	if t.p.withStats && cap(buf) > 0 {
+		atomic.AddUint64(&t.releases, 1)
+		atomic.AddUint64(&t.releaseBytes, uint64(cap(buf)))
+	}
+	return t.p.Release(buf)
+}
+
+func (t *TaggedPool) Put(buf []byte) {
+	t.Release(buf)
+}
+
+// Stat returns the statistic of the tag.
+func (t *TaggedPool) Stat() TagStat {
+	st := TagStat{
+		Tag:      t.tag,
+		Gets:     atomic.LoadUint64(&t.gets),
+		Bytes:    atomic.LoadUint64(&t.bytes),
+		Releases: atomic.LoadUint64(&t.releases),
+	}
+	st.Outstanding = outstanding(st.Gets, st.Releases)
+	st.OutstandingBytes = outstanding(st.Bytes, atomic.LoadUint64(&t.releaseBytes))
+	return st
+}
+
+// getTagStats returns the statistics of every tag ordered by tag.
+func (p *CapacityPools) getTagStats() []TagStat {
+	var stats []TagStat
+	p.tags.Range(func(_, v interface{}) bool {
+		stats = append(stats, v.(*TaggedPool).Stat())
+		return true
+	})
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Tag < stats[j].Tag })
+	return stats
+}
+
+// Tagged returns the view of the default pools for tag.
+func Tagged(tag string) *TaggedPool {
+	return Default().Tagged(tag)
+}
+
+// NewTagged is New accounted to tag.
+func NewTagged(tag string, size int) []byte {
+	return Default().Tagged(tag).New(size)
+}
+
+// MakeTagged is Make accounted to tag.
+func MakeTagged(tag string, capacity int) []byte {
+	return Default().Tagged(tag).Make(capacity)
+}
+
+// ReleaseTagged is Release accounted to tag.
+func ReleaseTagged(tag string, buf []byte) bool {
+	return Default().Tagged(tag).Release(buf)
+}
+
+// TagStats returns the statistics of every tag of the provided CapacityPools
+// (or the default pools when none provided), ordered by tag.
+// It returns nil if statistics collection is disabled.
+func TagStats(ps ...*CapacityPools) []TagStat {
+	p := Default()
+	if len(ps) > 0 {
+		p = ps[0]
+	}
+
+	if !p.GetWithStats() {
+		return nil
+	}
+
+	return p.getTagStats()
+}
@@ -0,0 +1,98 @@
+package bytespool
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTaggedPool(t *testing.T) {
+	p := NewCapacityPools(8, 64)
+	p.SetWithStats(true)
+	proxy := p.Tagged("proxy")
+	if p.Tagged("proxy") != proxy || proxy.Tag() != "proxy" || proxy.Pools() != p {
+		t.Fatal("expect the same view for the same tag")
+	}
+	cache := p.Tagged("cache")
+
+	a := proxy.New(20)
+	b := proxy.Make(8)
+	b = proxy.AppendString(b, "0123456789")
+	if string(b) != "0123456789" || cap(b) != 16 {
+		t.Fatalf("unexpected append result: %q, cap %d", b, cap(b))
+	}
+	c := cache.NewString("abc")
+	proxy.Release(a)
+
+	stats := TagStats(p)
+	if len(stats) != 2 || stats[0].Tag != "cache" || stats[1].Tag != "proxy" {
+		t.Fatalf("unexpected tags: %+v", stats)
+	}
+	want := TagStat{Tag: "proxy", Gets: 3, Bytes: 32 + 8 + 16, Releases: 2, Outstanding: 1, OutstandingBytes: 16}
+	if stats[1] != want {
+		t.Fatalf("expect %+v, but got %+v", want, stats[1])
+	}
+	if stats[0].Outstanding != 1 || stats[0].OutstandingBytes != 8 {
+		t.Fatalf("unexpected cache stat: %+v", stats[0])
+	}
+
+	// the underlying pools are shared
+	summary := RuntimeStatsSummary(0, p)
+	if summary.Outstanding != 2 || len(summary.Tags) != 2 {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+	if !strings.Contains(summary.String(), "outstanding bytes") {
+		t.Fatalf("expect a tag table, but got:\n%s", summary)
+	}
+	cache.Put(c)
+	proxy.Put(b)
+	if st := proxy.Stat(); st.Outstanding != 0 || st.OutstandingBytes != 0 {
+		t.Fatalf("expect nothing outstanding, but got %+v", st)
+	}
+}
+
+func TestTaggedPool_AppendAboveMaxSize(t *testing.T) {
+	p := NewCapacityPools(8, 64)
+	p.SetWithStats(true)
+	x := p.Tagged("x")
+	buf := x.Make(8)
+	for i := 0; i < 4096; i++ {
+		buf = x.Append(buf, 'a')
+	}
+	buf = x.AppendString(buf, "bc")
+	if len(buf) != 4098 || buf[4096] != 'b' {
+		t.Fatalf("unexpected append result: len %d", len(buf))
+	}
+	// 8, 16, 32, 64 and 65 out of range, then the built-in append grows the byte slice
+	if st := x.Stat(); st.Gets != 5 {
+		t.Fatalf("expect 5 gets, but got %+v", st)
+	}
+}
+
+func TestTaggedPool_WithoutStats(t *testing.T) {
+	p := NewCapacityPools(8, 64)
+	buf := p.Tagged("x").New(10)
+	if len(buf) != 10 || cap(buf) != 16 {
+		t.Fatalf("unexpected buf: len %d, cap %d", len(buf), cap(buf))
+	}
+	if !p.Tagged("x").Release(buf) {
+		t.Fatal("expect to release the buffer successfully, but not")
+	}
+	if TagStats(p) != nil || p.Tagged("x").Stat().Gets != 0 {
+		t.Fatal("expect no tag statistics when statistics collection is disabled")
+	}
+}
+
+func TestTagged_Default(t *testing.T) {
+	buf := MakeTagged("default-test", 10)
+	buf = append(buf, "x"...)
+	if !ReleaseTagged("default-test", buf) {
+		t.Fatal("expect to release the buffer successfully, but not")
+	}
+	if Tagged("default-test") != Default().Tagged("default-test") {
+		t.Fatal("expect the same view for the same tag")
+	}
+	buf = NewTagged("default-test", 3)
+	if len(buf) != 3 {
+		t.Fatalf("expect buffer len is 3, but got %d", len(buf))
+	}
+}